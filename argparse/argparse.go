@@ -6,18 +6,43 @@ import (
 	"strings"
 )
 
+// Result holds all values of the parsed arguments in the order of their occurrence.
+type Result struct {
+	values      map[string][]string
+	passthrough []string
+}
+
 // ParseArgs parses the arguments from the os.Args (ignoring the first one).
 func ParseArgs() map[string]string {
 	return ParseArgString(os.Args[1:])
 }
 
 // ParseArgString parses the arguments from a given array.
+// If an argument occurs multiple times, the last value is used.
 func ParseArgString(args []string) map[string]string {
-	argsMap := make(map[string]string)
+	return ParseArgStringResult(args).Map()
+}
+
+// ParseArgsResult parses the arguments from the os.Args (ignoring the first one) and keeps all occurrences.
+func ParseArgsResult() *Result {
+	return ParseArgStringResult(os.Args[1:])
+}
+
+// ParseArgStringResult parses the arguments from a given array and keeps all occurrences.
+// A negated flag like "--no-cache" is kept and also sets the flag "cache" to "false".
+// All arguments after "--" are not parsed and kept verbatim as passthrough arguments.
+func ParseArgStringResult(args []string) *Result {
+	result := &Result{values: map[string][]string{}, passthrough: []string{}}
 
 	var lastKey = ""
 	var nextCanBeValue = false
-	for _, arg := range args {
+	for i, arg := range args {
+		// Passthrough arguments
+		if arg == "--" {
+			result.passthrough = append(result.passthrough, args[i+1:]...)
+			break
+		}
+
 		// Long Options
 		if strings.HasPrefix(arg, "--") {
 			if strings.Contains(arg, "=") {
@@ -26,14 +51,18 @@ func ParseArgString(args []string) map[string]string {
 				keyPart := parts[0]
 				valuePart := parts[1]
 				key := keyPart[2:]
-				argsMap[key] = valuePart
+				result.add(key, valuePart)
 				lastKey = ""
 			} else {
 				// Key only
 				key := arg[2:]
 				lastKey = key
 				nextCanBeValue = true
-				argsMap[key] = ""
+				result.add(key, "")
+				if negatedKey, isNegated := strings.CutPrefix(key, "no-"); isNegated && negatedKey != "" {
+					// Negated flag, so also set the flag itself to false
+					result.add(negatedKey, "false")
+				}
 			}
 			continue
 		}
@@ -44,7 +73,7 @@ func ParseArgString(args []string) map[string]string {
 			if len(optionChars) > 1 {
 				// Multiple flags, so they do not have a value
 				for _, c := range optionChars {
-					argsMap[string(c)] = ""
+					result.add(string(c), "")
 				}
 				lastKey = ""
 			} else {
@@ -52,7 +81,7 @@ func ParseArgString(args []string) map[string]string {
 				key := optionChars
 				lastKey = key
 				nextCanBeValue = true
-				argsMap[key] = ""
+				result.add(key, "")
 			}
 			continue
 		}
@@ -60,15 +89,78 @@ func ParseArgString(args []string) map[string]string {
 		// The current value is no key so it seems to be a value to a previous key
 		if nextCanBeValue && len(lastKey) > 0 {
 			nextCanBeValue = false
-			argsMap[lastKey] = arg
+			result.setLast(lastKey, arg)
 			continue
 		}
 	}
 
-	// Make sure to add the last key when no value was provided
-	if nextCanBeValue && len(lastKey) > 0 {
-		argsMap[lastKey] = ""
-	}
+	return result
+}
+
+// add adds a new occurrence of the argument with the given value.
+func (result *Result) add(key string, value string) {
+	result.values[key] = append(result.values[key], value)
+}
 
+// setLast sets the value of the last occurrence of the argument.
+func (result *Result) setLast(key string, value string) {
+	values := result.values[key]
+	values[len(values)-1] = value
+}
+
+// Map returns a map with the last value of each argument.
+func (result *Result) Map() map[string]string {
+	argsMap := make(map[string]string)
+	for key, values := range result.values {
+		argsMap[key] = values[len(values)-1]
+	}
 	return argsMap
 }
+
+// Occurrences returns a map with all values of each argument in the order of their occurrence.
+func (result *Result) Occurrences() map[string][]string {
+	occurrences := make(map[string][]string)
+	for key, values := range result.values {
+		occurrences[key] = append([]string{}, values...)
+	}
+	return occurrences
+}
+
+// Passthrough returns the arguments after "--" exactly as they were given.
+func (result *Result) Passthrough() []string {
+	return append([]string{}, result.passthrough...)
+}
+
+// Get returns the last value of the argument with the given name
+// and also a flag, if the argument was present or not.
+func (result *Result) Get(name string) (string, bool) {
+	values, exists := result.values[name]
+	if !exists {
+		return "", false
+	}
+	return values[len(values)-1], true
+}
+
+// GetAll returns all values of the argument with the given name in the order of their occurrence.
+func (result *Result) GetAll(name string) []string {
+	return append([]string{}, result.values[name]...)
+}
+
+// GetList returns all values of the argument with the given name where each value is also split by comma.
+// Empty entries are removed, so "--tag a --tag b,c" returns a, b and c.
+func (result *Result) GetList(name string) []string {
+	return SplitList(result.values[name]...)
+}
+
+// SplitList splits the given comma separated values into their trimmed, non-empty entries.
+func SplitList(values ...string) []string {
+	entries := []string{}
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
@@ -126,3 +126,68 @@ func TestComplex4(t *testing.T) {
 	assert.Empty(argsMap["c"])
 	assert.Empty(argsMap["d"])
 }
+
+func TestRepeated(t *testing.T) {
+	assert := assert.New(t)
+
+	result := ParseArgStringResult(strings.Fields("--tag a --tag=b,c -v --tag d -v"))
+
+	assert.Equal([]string{"a", "b,c", "d"}, result.GetAll("tag"))
+	assert.Equal([]string{"a", "b", "c", "d"}, result.GetList("tag"))
+	assert.Equal([]string{"", ""}, result.GetAll("v"))
+	value, exists := result.Get("tag")
+	assert.True(exists)
+	assert.Equal("d", value)
+	assert.Equal(map[string]string{"tag": "d", "v": ""}, result.Map())
+	assert.Equal(map[string][]string{"tag": {"a", "b,c", "d"}, "v": {"", ""}}, result.Occurrences())
+}
+
+func TestRepeatedMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	result := ParseArgStringResult(strings.Fields("--name test"))
+
+	_, exists := result.Get("tag")
+	assert.False(exists)
+	assert.Empty(result.GetAll("tag"))
+	assert.Empty(result.GetList("tag"))
+}
+
+func TestSplitList(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"a", "b", "c"}, SplitList(" a, ,b", "c,"))
+	assert.Equal([]string{}, SplitList(""))
+}
+
+func TestPassthrough(t *testing.T) {
+	assert := assert.New(t)
+
+	result := ParseArgStringResult(strings.Fields("--target Npm:Run -v -- --watch -x --name=test value --"))
+
+	assert.Equal(map[string]string{"target": "Npm:Run", "v": ""}, result.Map())
+	assert.Equal([]string{"--watch", "-x", "--name=test", "value", "--"}, result.Passthrough())
+}
+
+func TestPassthroughEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	result := ParseArgStringResult(strings.Fields("--name test --"))
+
+	assert.Equal(map[string]string{"name": "test"}, result.Map())
+	assert.Empty(result.Passthrough())
+	assert.Empty(ParseArgStringResult(strings.Fields("--name test")).Passthrough())
+}
+
+func TestNegatedFlag(t *testing.T) {
+	assert := assert.New(t)
+
+	result := ParseArgStringResult(strings.Fields("--verbose --no-verbose --no-cache --cache=true --no-"))
+
+	assert.Equal([]string{"", "false"}, result.GetAll("verbose"))
+	assert.Equal([]string{"false", "true"}, result.GetAll("cache"))
+	assert.Contains(result.Map(), "no-verbose")
+	assert.Contains(result.Map(), "no-cache")
+	assert.Contains(result.Map(), "no-")
+	assert.Equal(5, len(result.Map()))
+}
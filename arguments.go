@@ -0,0 +1,311 @@
+package gotaskr
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roemer/goext"
+	"github.com/roemer/gotaskr/internal/secrets"
+)
+
+// Regex to find characters which are not allowed in the conventional environment variable names.
+var invalidEnvVarCharsRegex = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// argumentType defines the type of the value of an argument.
+type argumentType string
+
+const (
+	argumentTypeString   argumentType = ""
+	argumentTypeInt      argumentType = "int"
+	argumentTypeBool     argumentType = "bool"
+	argumentTypeEnum     argumentType = "enum"
+	argumentTypeDuration argumentType = "duration"
+)
+
+// ArgumentInt adds an integer argument with a default value.
+// The value is validated before any task runs and can be read with GetArgumentInt.
+func (taskObject *TaskObject) ArgumentInt(argumentName string, argumentDescription string, defaultValue int) *TaskObject {
+	return taskObject.addTypedArgument(argumentName, argumentDescription, argumentTypeInt, strconv.Itoa(defaultValue))
+}
+
+// ArgumentBool adds a boolean argument with a default value. Setting the argument without a value means true
+// and it can be turned off with "--no-<name>".
+// The value is validated before any task runs and can be read with GetArgumentBool.
+func (taskObject *TaskObject) ArgumentBool(argumentName string, argumentDescription string, defaultValue bool) *TaskObject {
+	return taskObject.addTypedArgument(argumentName, argumentDescription, argumentTypeBool, strconv.FormatBool(defaultValue))
+}
+
+// ArgumentEnum adds an argument which only accepts one of the allowed values with a default value.
+// The default value must be one of the allowed values, otherwise the run fails before any task runs.
+// The value is validated before any task runs and can be read with GetArgumentEnum.
+func (taskObject *TaskObject) ArgumentEnum(argumentName string, argumentDescription string, defaultValue string, allowedValues ...string) *TaskObject {
+	taskObject.addTypedArgument(argumentName, argumentDescription, argumentTypeEnum, defaultValue)
+	arg := &taskObject.arguments[len(taskObject.arguments)-1]
+	arg.allowedValues = allowedValues
+	arg.defaultErr = arg.validate(defaultValue)
+	return taskObject
+}
+
+// ArgumentDuration adds a duration argument (like "1m30s") with a default value.
+// The value is validated before any task runs and can be read with GetArgumentDuration.
+func (taskObject *TaskObject) ArgumentDuration(argumentName string, argumentDescription string, defaultValue time.Duration) *TaskObject {
+	return taskObject.addTypedArgument(argumentName, argumentDescription, argumentTypeDuration, defaultValue.String())
+}
+
+// FromEnv binds the argument which was declared last to the given environment variable.
+// The value of the environment variable is used if the argument is not passed on the command line.
+// Has no effect if no argument was declared yet.
+func (taskObject *TaskObject) FromEnv(envVar string) *TaskObject {
+	if len(taskObject.arguments) > 0 {
+		taskObject.arguments[len(taskObject.arguments)-1].envVar = envVar
+	}
+	return taskObject
+}
+
+// Secret marks the argument which was declared last as secret so its value is masked in all output.
+// Has no effect if no argument was declared yet.
+func (taskObject *TaskObject) Secret() *TaskObject {
+	if len(taskObject.arguments) > 0 {
+		taskObject.arguments[len(taskObject.arguments)-1].secret = true
+	}
+	return taskObject
+}
+
+func (taskObject *TaskObject) addTypedArgument(argumentName string, argumentDescription string, argType argumentType, defaultValue string) *TaskObject {
+	taskObject.arguments = append(taskObject.arguments, argument{
+		name:         argumentName,
+		description:  argumentDescription,
+		optional:     true,
+		argType:      argType,
+		defaultValue: defaultValue,
+	})
+	return taskObject
+}
+
+// GetArgumentInt returns the value of the argument with the given name as integer.
+// Returns the declared default value or 0 if the argument is not set.
+func GetArgumentInt(argName string) (int, error) {
+	value, exists := getArgumentOrDeclaredDefault(argName)
+	if !exists {
+		return 0, nil
+	}
+	return parseInt(value)
+}
+
+// GetArgumentBool returns the value of the argument with the given name as boolean.
+// The values true/false, 1/0 and yes/no are supported and an argument without a value is true.
+// The argument can be turned off with "--no-<name>".
+// Returns the declared default value or false if the argument is not set.
+func GetArgumentBool(argName string) (bool, error) {
+	value, exists := getArgumentOrDeclaredDefault(argName)
+	if !exists {
+		return false, nil
+	}
+	return parseBool(value)
+}
+
+// GetArgumentDuration returns the value of the argument with the given name as duration.
+// Returns the declared default value or 0 if the argument is not set.
+func GetArgumentDuration(argName string) (time.Duration, error) {
+	value, exists := getArgumentOrDeclaredDefault(argName)
+	if !exists {
+		return 0, nil
+	}
+	return parseDuration(value)
+}
+
+// GetArgumentEnum returns the value of the enum argument with the given name.
+// Returns the declared default value or an empty string if the argument is not set.
+func GetArgumentEnum(argName string) (string, error) {
+	value, exists := getArgumentOrDeclaredDefault(argName)
+	if !exists {
+		return "", nil
+	}
+	for _, taskName := range taskList {
+		for _, arg := range taskMap[taskName].arguments {
+			if arg.name == argName && arg.argType == argumentTypeEnum {
+				return value, arg.validate(value)
+			}
+		}
+	}
+	return value, nil
+}
+
+// getArgumentEnvironmentVariables gets the environment variables for the argument with the given name in the order of precedence.
+// These are the variables bound with FromEnv followed by GOTASKR_ARG_<NAME>.
+func getArgumentEnvironmentVariables(argName string) []string {
+	envVars := []string{}
+	for _, taskName := range taskList {
+		for _, arg := range taskMap[taskName].arguments {
+			if arg.name == argName && arg.envVar != "" {
+				envVars = goext.SliceAppendIfMissing(envVars, arg.envVar)
+			}
+		}
+	}
+	return append(envVars, getConventionalEnvironmentVariable(argName))
+}
+
+// getConventionalEnvironmentVariable gets the name of the environment variable GOTASKR_ARG_<NAME> for the given argument.
+// The name is uppercased and all characters other than letters and digits are replaced with '_'.
+func getConventionalEnvironmentVariable(argName string) string {
+	return "GOTASKR_ARG_" + invalidEnvVarCharsRegex.ReplaceAllString(strings.ToUpper(argName), "_")
+}
+
+// registerSecretArguments registers the values of all arguments which are marked as secret.
+func registerSecretArguments() {
+	for _, taskName := range taskList {
+		for _, arg := range taskMap[taskName].arguments {
+			if !arg.secret {
+				continue
+			}
+			if value, exists := GetArgument(arg.name); exists {
+				secrets.Register(value)
+			}
+		}
+	}
+}
+
+// getArgumentOrDeclaredDefault gets the value of the argument or the default value of the first task which declared it.
+func getArgumentOrDeclaredDefault(argName string) (string, bool) {
+	if value, exists := GetArgument(argName); exists {
+		return value, true
+	}
+	for _, taskName := range taskList {
+		for _, arg := range taskMap[taskName].arguments {
+			if arg.name == argName && arg.argType != argumentTypeString {
+				return arg.defaultValue, true
+			}
+		}
+	}
+	return "", false
+}
+
+// findMissingArguments finds the required arguments of the given tasks which are not set.
+// Tasks which are excluded from the run do not need their arguments.
+// Returns an error with the description for each missing argument.
+func findMissingArguments(tasks []*TaskObject) []error {
+	errs := []error{}
+	for _, task := range tasks {
+		if isExcluded(task.name) {
+			continue
+		}
+		for _, arg := range task.arguments {
+			if arg.optional || HasArgument(arg.name) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("missing required argument '%s' of task '%s': %s", arg.name, task.name, arg.description))
+		}
+	}
+	return errs
+}
+
+// validateArguments validates the values of the typed arguments of the given tasks.
+// Tasks which are excluded from the run are ignored.
+// Returns an error for each invalid value.
+func validateArguments(tasks []*TaskObject) []error {
+	errs := []error{}
+	for _, task := range tasks {
+		if isExcluded(task.name) {
+			continue
+		}
+		for _, arg := range task.arguments {
+			value, exists := GetArgument(arg.name)
+			if !exists {
+				continue
+			}
+			if err := arg.validate(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value '%s' for argument '%s' of task '%s': %w", value, arg.name, task.name, err))
+			}
+		}
+	}
+	return errs
+}
+
+// validate checks if the given value is valid for the type of the argument.
+func (arg *argument) validate(value string) error {
+	var err error
+	switch arg.argType {
+	case argumentTypeInt:
+		_, err = parseInt(value)
+	case argumentTypeBool:
+		_, err = parseBool(value)
+	case argumentTypeDuration:
+		_, err = parseDuration(value)
+	case argumentTypeEnum:
+		if !slices.Contains(arg.allowedValues, value) {
+			err = fmt.Errorf("expected one of %s", strings.Join(arg.allowedValues, ", "))
+		}
+	}
+	return err
+}
+
+// formatDetails formats the type, default value and environment variable of the argument for the help.
+func (arg *argument) formatDetails() string {
+	details := []string{}
+	switch arg.argType {
+	case argumentTypeString:
+		if arg.optional {
+			details = append(details, "optional")
+		}
+	case argumentTypeEnum:
+		details = append(details, fmt.Sprintf("one of %s", strings.Join(arg.allowedValues, "|")), fmt.Sprintf("default: %s", arg.defaultValue))
+	default:
+		details = append(details, string(arg.argType), fmt.Sprintf("default: %s", arg.defaultValue))
+	}
+	if arg.envVar != "" {
+		details = append(details, fmt.Sprintf("env: %s", arg.envVar))
+	}
+	if arg.secret {
+		details = append(details, "secret")
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+}
+
+func parseInt(value string) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("expected an integer")
+	}
+	return number, nil
+}
+
+// parseBool parses true/false, 1/0 and yes/no case-insensitively. An empty value is true.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "true", "1", "yes":
+		return true, nil
+	case "false", "0", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected true/false, 1/0 or yes/no")
+}
+
+// isFlagSet checks if any of the given flags is set and not turned off with a value like "false" or with "--no-<flag>".
+// Invalid values count as set.
+func isFlagSet(flagNames ...string) bool {
+	for _, flagName := range flagNames {
+		value, exists := GetArgument(flagName)
+		if !exists {
+			continue
+		}
+		if flag, err := parseBool(value); err != nil || flag {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("expected a duration like 1m30s")
+	}
+	return duration, nil
+}
@@ -0,0 +1,191 @@
+package gotaskr
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/roemer/goext"
+)
+
+// The arguments which are handled by gotaskr itself.
+var builtInArguments = []string{
+	"target", "skip", "tags", "list", "exclusive", "verbose", "dry-run", "parallel", "timeout",
+	"graph", "report-json", "report-junit", "state-dir", "rerun-failed", "config", "env-file", "completion",
+}
+
+// The shells for which a completion script can be generated.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// Regex to find characters which are not allowed in shell function names.
+var invalidFunctionNameCharsRegex = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// The completion scripts call the binary with "--__complete -- <previous word> <current word>" to get the candidates.
+const bashCompletionScript = `# bash completion for {{name}} generated by gotaskr
+_{{function}}_completions() {
+    local cur prev
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        _get_comp_words_by_ref -n =: cur prev
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        prev="${COMP_WORDS[COMP_CWORD-1]}"
+    fi
+    local IFS=$'\n'
+    COMPREPLY=($("${COMP_WORDS[0]}" --__complete -- "${prev}" "${cur}" 2>/dev/null))
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "${cur}"
+    fi
+    if [[ "${cur}" == *=* && "${COMP_WORDBREAKS}" == *=* ]]; then
+        local name_prefix="${cur%%=*}="
+        COMPREPLY=("${COMPREPLY[@]#"${name_prefix}"}")
+    fi
+}
+complete -o default -F _{{function}}_completions {{name}}
+`
+
+const zshCompletionScript = `#compdef {{name}}
+# zsh completion for {{name}} generated by gotaskr
+_{{function}}() {
+    local -a candidates
+    candidates=("${(@f)$("${words[1]}" --__complete -- "${words[CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    compadd -Q -S '' -a candidates
+}
+compdef _{{function}} {{name}}
+`
+
+const fishCompletionScript = `# fish completion for {{name}} generated by gotaskr
+function __{{function}}_complete
+    set -l tokens (commandline -opc)
+    $tokens[1] --__complete -- "$tokens[-1]" (commandline -ct) 2>/dev/null
+end
+complete -c {{name}} -f -a '(__{{function}}_complete)'
+`
+
+// writeCompletionScript writes the completion script for the given shell.
+// The script is registered for the name of the current binary.
+func writeCompletionScript(w io.Writer, shell string) error {
+	scripts := map[string]string{
+		"bash": bashCompletionScript,
+		"zsh":  zshCompletionScript,
+		"fish": fishCompletionScript,
+	}
+	script, exists := scripts[shell]
+	if !exists {
+		return fmt.Errorf("unsupported shell for completion: %s (supported: %s)", shell, strings.Join(completionShells, ", "))
+	}
+	name := filepath.Base(os.Args[0])
+	replacer := strings.NewReplacer(
+		"{{name}}", name,
+		"{{function}}", invalidFunctionNameCharsRegex.ReplaceAllString(name, "_"),
+	)
+	_, err := io.WriteString(w, replacer.Replace(script))
+	return err
+}
+
+// writeCompletions writes the completion candidates for the given previous and current word, one per line.
+func writeCompletions(w io.Writer, words []string) error {
+	previous, current := "", ""
+	if len(words) > 0 {
+		previous = words[0]
+	}
+	if len(words) > 1 {
+		current = words[1]
+	}
+	var sb strings.Builder
+	for _, candidate := range getCompletions(previous, current) {
+		sb.WriteString(candidate)
+		sb.WriteString("\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// getCompletions gets the completion candidates for the current word.
+// Argument names are completed if the current word starts with '-', otherwise the values for the previous argument.
+func getCompletions(previous string, current string) []string {
+	prefix := ""
+	// Handle values in the form --name=value
+	if strings.HasPrefix(current, "--") && strings.Contains(current, "=") {
+		name, value, _ := strings.Cut(current, "=")
+		previous, current, prefix = name, value, name+"="
+	} else if strings.HasPrefix(current, "-") {
+		return filterCompletions(getArgumentNames(), "", current)
+	}
+	argName, isArgument := strings.CutPrefix(previous, "--")
+	if !isArgument {
+		return []string{}
+	}
+	values := getArgumentValues(argName)
+	if slices.Contains([]string{"target", "skip", "tags"}, argName) {
+		// Lists can contain multiple values separated by a comma
+		if index := strings.LastIndex(current, ","); index >= 0 {
+			prefix += current[:index+1]
+			current = current[index+1:]
+		}
+	}
+	return filterCompletions(values, prefix, current)
+}
+
+// getArgumentNames gets all built-in arguments and the arguments declared by the tasks.
+func getArgumentNames() []string {
+	names := []string{}
+	for _, name := range builtInArguments {
+		names = goext.SliceAppendIfMissing(names, "--"+name)
+	}
+	for _, taskName := range taskList {
+		for _, arg := range taskMap[taskName].arguments {
+			names = goext.SliceAppendIfMissing(names, "--"+arg.name)
+		}
+	}
+	return names
+}
+
+// getArgumentValues gets the possible values of the argument with the given name.
+func getArgumentValues(argName string) []string {
+	switch argName {
+	case "target", "skip":
+		return slices.Clone(taskList)
+	case "tags":
+		tags := []string{}
+		for _, taskName := range taskList {
+			for _, tag := range taskMap[taskName].tags {
+				tags = goext.SliceAppendIfMissing(tags, tag)
+			}
+		}
+		return tags
+	case "graph":
+		return []string{string(GraphFormatDot), string(GraphFormatMermaid)}
+	case "completion":
+		return slices.Clone(completionShells)
+	}
+	for _, taskName := range taskList {
+		for _, arg := range taskMap[taskName].arguments {
+			if arg.name != argName {
+				continue
+			}
+			switch arg.argType {
+			case argumentTypeEnum:
+				return slices.Clone(arg.allowedValues)
+			case argumentTypeBool:
+				return []string{"true", "false"}
+			}
+		}
+	}
+	return []string{}
+}
+
+// filterCompletions gets the candidates which start with the current word and adds the given prefix.
+func filterCompletions(candidates []string, prefix string, current string) []string {
+	completions := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			completions = append(completions, prefix+candidate)
+		}
+	}
+	return completions
+}
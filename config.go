@@ -0,0 +1,207 @@
+package gotaskr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/roemer/gotaskr/argparse"
+	"github.com/roemer/gotaskr/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// The config files which are loaded by default if they exist.
+var defaultConfigFiles = []string{"gotaskr.yaml", "gotaskr.yml", "gotaskr.json"}
+
+// The env file which is loaded by default if it exists.
+const defaultEnvFile = ".env"
+
+// The arguments loaded from the config file. They have a lower precedence than the arguments from the CLI.
+var configArguments = map[string]string{}
+
+// loadConfig loads the arguments from the config file and the environment variables from the env files.
+// The files can be set with the "config" and "env-file" arguments, otherwise the default files are loaded if they exist.
+func loadConfig() error {
+	configArguments = map[string]string{}
+	// Load the env files
+	envFiles := []string{defaultEnvFile}
+	envFilesRequired := false
+	if value, exists := argumentsMap["env-file"]; exists {
+		envFiles = argparse.SplitList(value)
+		envFilesRequired = true
+	}
+	for _, envFile := range envFiles {
+		if err := loadEnvFile(envFile, envFilesRequired); err != nil {
+			return fmt.Errorf("failed to load env file '%s': %w", envFile, err)
+		}
+	}
+
+	// Load the config file
+	configFiles := defaultConfigFiles
+	configFileRequired := false
+	if value, exists := argumentsMap["config"]; exists {
+		configFiles = []string{value}
+		configFileRequired = true
+	}
+	for _, configFile := range configFiles {
+		arguments, err := loadConfigFile(configFile)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && !configFileRequired {
+				continue
+			}
+			return fmt.Errorf("failed to load config file '%s': %w", configFile, err)
+		}
+		configArguments = arguments
+		break
+	}
+	return nil
+}
+
+// loadConfigFile loads the arguments from the given yaml or json file.
+// The file contains a map from the argument names to their values. Lists are joined with a comma.
+// The values are taken as written in the file, so numbers like 1.10 keep their formatting.
+func loadConfigFile(configFile string) (map[string]string, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(configFile), ".json") {
+		return parseJsonConfig(data)
+	}
+	return parseYamlConfig(data)
+}
+
+// parseJsonConfig parses the arguments from the given json data.
+func parseJsonConfig(data []byte) (map[string]string, error) {
+	values := map[string]any{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Keep the numbers as written instead of converting them to floats
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	arguments := map[string]string{}
+	for name, value := range values {
+		switch typedValue := value.(type) {
+		case nil:
+			arguments[name] = ""
+		case []any:
+			arguments[name] = utils.StringsJoinAny(typedValue, ",")
+		case map[string]any:
+			return nil, fmt.Errorf("the value of argument '%s' must not be a map", name)
+		default:
+			arguments[name] = fmt.Sprint(typedValue)
+		}
+	}
+	return arguments, nil
+}
+
+// parseYamlConfig parses the arguments from the given yaml data.
+// The nodes are used instead of decoded values to keep the scalars as written.
+func parseYamlConfig(data []byte) (map[string]string, error) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	arguments := map[string]string{}
+	if len(document.Content) == 0 || isYamlNull(document.Content[0]) {
+		return arguments, nil
+	}
+	root := resolveYamlAlias(document.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the config must be a map from the argument names to their values")
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name := root.Content[i].Value
+		valueNode := resolveYamlAlias(root.Content[i+1])
+		switch valueNode.Kind {
+		case yaml.ScalarNode:
+			arguments[name] = yamlScalarValue(valueNode)
+		case yaml.SequenceNode:
+			values := []string{}
+			for _, itemNode := range valueNode.Content {
+				itemNode = resolveYamlAlias(itemNode)
+				if itemNode.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf("the list of argument '%s' must only contain plain values", name)
+				}
+				values = append(values, yamlScalarValue(itemNode))
+			}
+			arguments[name] = strings.Join(values, ",")
+		default:
+			return nil, fmt.Errorf("the value of argument '%s' must not be a map", name)
+		}
+	}
+	return arguments, nil
+}
+
+// resolveYamlAlias returns the node an alias points to or the node itself.
+func resolveYamlAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// isYamlNull checks if the given node is a null value.
+func isYamlNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+// yamlScalarValue gets the value of the given scalar node as written. Null values are empty.
+func yamlScalarValue(node *yaml.Node) string {
+	if isYamlNull(node) {
+		return ""
+	}
+	return node.Value
+}
+
+// loadEnvFile sets the environment variables from the given env file. Existing environment variables are not overwritten.
+// The file contains lines in the form KEY=VALUE. Empty lines and lines starting with '#' are ignored.
+// Invalid lines fail the loading of a required file and are skipped with a warning otherwise,
+// so an existing env file of another tool (like docker compose) does not break the run.
+func loadEnvFile(envFile string, required bool) error {
+	file, err := os.Open(envFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			if required {
+				return fmt.Errorf("invalid line %d", lineNumber)
+			}
+			color.Yellow("Skipping invalid line %d of env file '%s'", lineNumber, envFile)
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
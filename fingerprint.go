@@ -0,0 +1,256 @@
+package gotaskr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/roemer/goext"
+)
+
+// taskFingerprint holds the hashes of the inputs and outputs of a task.
+type taskFingerprint struct {
+	Inputs  string `json:"inputs"`
+	Outputs string `json:"outputs"`
+}
+
+// Regex to find characters which are not allowed in file names.
+var invalidFileNameCharsRegex = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// The directory where gotaskr persists state between runs if no "state-dir" argument is given.
+var defaultStateDirectory = ".gotaskr"
+
+// getStateDirectory gets the directory where gotaskr persists state between runs.
+func getStateDirectory() string {
+	stateDirectory, _ := GetArgumentOrDefault("state-dir", defaultStateDirectory)
+	return stateDirectory
+}
+
+// usesFingerprint checks if the task declared inputs or outputs.
+func (taskObject *TaskObject) usesFingerprint() bool {
+	return len(taskObject.inputs) > 0 || len(taskObject.outputs) > 0
+}
+
+// isUpToDate checks if the inputs and outputs of the task did not change since the last successful run.
+func (taskObject *TaskObject) isUpToDate() (bool, error) {
+	stored, err := loadFingerprint(taskObject.name)
+	if err != nil || stored == nil {
+		return false, err
+	}
+	current, outputCount, err := computeFingerprint(taskObject)
+	if err != nil {
+		return false, err
+	}
+	if len(taskObject.outputs) > 0 && outputCount == 0 {
+		// The outputs are missing
+		return false, nil
+	}
+	return *stored == *current, nil
+}
+
+// updateFingerprint stores the current fingerprint of the task if it ran successfully or removes it otherwise.
+func (taskObject *TaskObject) updateFingerprint(success bool) error {
+	fingerprintPath := getFingerprintPath(taskObject.name)
+	if !success {
+		if err := os.Remove(fingerprintPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	fingerprint, _, err := computeFingerprint(taskObject)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fingerprintPath), os.ModePerm); err != nil {
+		return err
+	}
+	return goext.WriteJsonToFile(fingerprint, fingerprintPath, true)
+}
+
+func getFingerprintPath(taskName string) string {
+	return filepath.Join(getStateDirectory(), "fingerprints", invalidFileNameCharsRegex.ReplaceAllString(taskName, "_")+".json")
+}
+
+func loadFingerprint(taskName string) (*taskFingerprint, error) {
+	data, err := os.ReadFile(getFingerprintPath(taskName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	fingerprint := &taskFingerprint{}
+	if err := json.Unmarshal(data, fingerprint); err != nil {
+		// Treat an invalid fingerprint as missing
+		return nil, nil
+	}
+	return fingerprint, nil
+}
+
+// computeFingerprint computes the fingerprint of the inputs and outputs of the task.
+// Also returns the number of output files.
+func computeFingerprint(task *TaskObject) (*taskFingerprint, int, error) {
+	inputsHash, _, err := hashFiles(task.inputs)
+	if err != nil {
+		return nil, 0, err
+	}
+	outputsHash, outputCount, err := hashFiles(task.outputs)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &taskFingerprint{Inputs: inputsHash, Outputs: outputsHash}, outputCount, nil
+}
+
+// hashFiles computes a hash over the paths and contents of all files matching the given patterns.
+// Also returns the number of files.
+func hashFiles(patterns []string) (string, int, error) {
+	files, err := globFiles(patterns)
+	if err != nil {
+		return "", 0, err
+	}
+	hash := sha256.New()
+	for _, file := range files {
+		fileHash, err := hashFile(file)
+		if err != nil {
+			return "", 0, err
+		}
+		fmt.Fprintf(hash, "%s\x00%s\n", filepath.ToSlash(file), fileHash)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), len(files), nil
+}
+
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// globFiles gets all files matching the given patterns in sorted order.
+// The patterns support "**" to match any number of directories.
+// Directories which match a pattern are added with all their files.
+func globFiles(patterns []string) ([]string, error) {
+	files := []string{}
+	seen := map[string]bool{}
+	// The state directory changes on every run, so it must never be part of the fingerprint
+	stateDirectory, err := filepath.Abs(getStateDirectory())
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range patterns {
+		matches, err := globPattern(pattern, stateDirectory)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if err := filepath.WalkDir(match, func(filePath string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if isExcludedDirectory(filePath, entry, stateDirectory) {
+					return fs.SkipDir
+				}
+				if !entry.IsDir() && !seen[filePath] {
+					seen[filePath] = true
+					files = append(files, filePath)
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// globPattern gets all paths matching the given pattern. The given directory and its content are excluded.
+func globPattern(pattern string, excludedDirectory string) ([]string, error) {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(filepath.FromSlash(pattern))
+	}
+	// Search from the part of the pattern which contains no wildcards
+	segments := strings.Split(pattern, "/")
+	rootSegments := []string{}
+	for _, segment := range segments {
+		if strings.ContainsAny(segment, "*?[\\") {
+			break
+		}
+		rootSegments = append(rootSegments, segment)
+	}
+	root := strings.Join(rootSegments, "/")
+	if root == "" {
+		root = goext.Ternary(strings.HasPrefix(pattern, "/"), "/", ".")
+	}
+	matches := []string{}
+	err := filepath.WalkDir(filepath.FromSlash(root), func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if isExcludedDirectory(filePath, entry, excludedDirectory) {
+			return fs.SkipDir
+		}
+		matched, err := matchSegments(segments, strings.Split(filepath.ToSlash(filePath), "/"))
+		if err != nil {
+			return err
+		}
+		if matched {
+			matches = append(matches, filePath)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// isExcludedDirectory checks if the given entry is the excluded directory which is given as absolute path.
+func isExcludedDirectory(filePath string, entry fs.DirEntry, excludedDirectory string) bool {
+	if !entry.IsDir() {
+		return false
+	}
+	absPath, err := filepath.Abs(filePath)
+	return err == nil && absPath == excludedDirectory
+}
+
+// matchSegments matches the segments of a path against the segments of a pattern where "**" matches any number of segments.
+func matchSegments(patternSegments []string, pathSegments []string) (bool, error) {
+	if len(patternSegments) == 0 {
+		return len(pathSegments) == 0, nil
+	}
+	if patternSegments[0] == "**" {
+		for i := 0; i <= len(pathSegments); i++ {
+			matched, err := matchSegments(patternSegments[1:], pathSegments[i:])
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+	if len(pathSegments) == 0 {
+		return false, nil
+	}
+	matched, err := path.Match(patternSegments[0], pathSegments[0])
+	if err != nil || !matched {
+		return false, err
+	}
+	return matchSegments(patternSegments[1:], pathSegments[1:])
+}
@@ -3,21 +3,41 @@
 package gotaskr
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"os/signal"
+	"runtime"
 	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/roemer/goext"
 	"github.com/roemer/gotaskr/argparse"
 	"github.com/roemer/gotaskr/gttools"
+	"github.com/roemer/gotaskr/internal/secrets"
+	"github.com/roemer/gotaskr/internal/toolctx"
 	"github.com/roemer/gotaskr/log"
 )
 
+// Parse all passed arguments from the CLI
+var parsedArguments = argparse.ParseArgsResult()
+
 // Generate a map that holds all passed arguments from the CLI
-var argumentsMap = argparse.ParseArgs()
+var argumentsMap = parsedArguments.Map()
+
+// Generate a map that holds all occurrences of the passed arguments from the CLI
+var argumentOccurrences = parsedArguments.Occurrences()
+
+// The arguments after "--" from the CLI
+var passthroughArgs = parsedArguments.Passthrough()
 
 // Prepare a map for all the task objects
 var taskMap map[string]*TaskObject = make(map[string]*TaskObject)
@@ -28,11 +48,38 @@ var taskList []string
 // Prepare an array for the tasks that were run (in run order)
 var taskRun []*TaskObject
 
-// The task object of the currently running task
+// The task object of the last started task. Tasks which run in parallel must use the context to get their task object.
 var currentRunningTask *TaskObject
 
-// A context for the current gotaskr run
-var context gotaskrContext = gotaskrContext{}
+// The key of the running task in the context of a task
+type runningTaskKey struct{}
+
+// A mutex to guard the run state when tasks are run in parallel
+var runMutex sync.Mutex
+
+// A mutex to make sure the lifetime functions are never called concurrently when tasks are run in parallel
+var lifetimeMutex sync.Mutex
+
+// The number of workers to use for running tasks. Tasks are run sequentially if this is 1.
+var parallelWorkers = 1
+
+// The timeout for tasks which do not define their own timeout. No timeout is used if this is 0.
+var defaultTimeout time.Duration
+
+// The context of the current run which is cancelled when the run is interrupted
+var runCtx, cancelRun = context.WithCancel(context.Background())
+
+// The signal which interrupted the current run (if any)
+var receivedSignal os.Signal
+
+// The tasks which succeeded in the previous run when only the failed tasks should rerun
+var rerunSucceededTasks = map[string]bool{}
+
+// The writer for the colored output which masks the secrets
+var colorOutput = secrets.NewMaskingWriter(color.Output)
+
+// The lifetime methods for the current gotaskr run
+var lifetime gotaskrContext = gotaskrContext{}
 
 // Tools provides typed access to the various tools supported.
 var Tools *gttools.ToolsClient = gttools.CreateToolsClient()
@@ -43,16 +90,80 @@ func Noop() error { return nil }
 
 // Execute is the entry point of gotaskr.
 func Execute() int {
-	log.Initialize(HasArgument("verbose") || HasArgument("v"))
+	// Mask the secrets in the colored output
+	if color.Output != colorOutput {
+		color.Output = colorOutput
+	}
+	defer colorOutput.Flush()
+	// Only print the completion script or the completion candidates if requested
+	if shell, exists := GetArgument("completion"); exists {
+		if err := writeCompletionScript(os.Stdout, shell); err != nil {
+			color.Red("%v", err)
+			return 1
+		}
+		return 0
+	}
+	if HasArgument("__complete") {
+		if err := writeCompletions(os.Stdout, GetPassthroughArgs()); err != nil {
+			return 1
+		}
+		return 0
+	}
+	// Load the arguments from the config and env files
+	if err := loadConfig(); err != nil {
+		color.Red("%v", err)
+		return 1
+	}
+	log.Initialize(isFlagSet("verbose", "v"))
+	registerSecretArguments()
+
+	// Only export the task graph if requested
+	if format, exists := GetArgument("graph"); exists {
+		if err := ExportGraph(os.Stdout, GraphFormat(goext.Ternary(format == "", string(GraphFormatDot), format))); err != nil {
+			color.Red("%v", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Only list the tasks if requested
+	if isFlagSet("list") {
+		printTasks("Available tasks:")
+		return 0
+	}
 
-	target, hasTarget := GetArgument("target")
-	if !hasTarget {
+	targets := getTargets()
+	// Rerun only the failed tasks of the previous run
+	if isFlagSet("rerun-failed") {
+		previousRun, err := loadRunState()
+		if err != nil {
+			color.Red("Failed to load the previous run: %v", err)
+			return 1
+		}
+		if len(targets) == 0 {
+			targets = previousRun.Targets
+		}
+		rerunSucceededTasks = previousRun.getSucceededTasks()
+	}
+	// Add the tasks with the given tags
+	if HasArgument("tags") {
+		tags := GetArgumentList("tags")
+		taggedTasks := getTasksWithTags(tags)
+		if len(taggedTasks) == 0 {
+			color.Red("No task with the tags '%s' exists.", strings.Join(tags, ","))
+			return 1
+		}
+		for _, taskName := range taggedTasks {
+			targets = goext.SliceAppendIfMissing(targets, taskName)
+		}
+	}
+	if len(targets) == 0 {
 		if taskMap["default"] != nil {
-			target = "default"
+			targets = []string{"default"}
 		} else if taskMap["Default"] != nil {
-			target = "Default"
+			targets = []string{"Default"}
 		} else {
-			printTasks()
+			printTasks("Please specify one of the following targets:")
 			return 0
 		}
 	}
@@ -63,6 +174,22 @@ func Execute() int {
 	log.Information(strings.Repeat("-", 60))
 	printArguments()
 	log.Information()
+	// Get the number of parallel workers
+	workers, err := getParallelWorkers()
+	if err != nil {
+		color.Red("%v", err)
+		return 1
+	}
+	parallelWorkers = workers
+	// Get the default timeout for the tasks
+	if value, exists := GetArgument("timeout"); exists {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			color.Red("invalid value for timeout: %s", value)
+			return 1
+		}
+		defaultTimeout = timeout
+	}
 	// Validate dependencies and convert dependees to dependencies
 	for _, task := range taskMap {
 		for _, followup := range task.followups {
@@ -87,23 +214,69 @@ func Execute() int {
 			}
 			dependeeTask.DependsOn(task.name)
 		}
+		for _, arg := range task.arguments {
+			if arg.defaultErr != nil {
+				color.Red("Invalid default value '%s' for argument '%s' of '%s': %v", arg.defaultValue, arg.name, task.name, arg.defaultErr)
+				return 1
+			}
+		}
+	}
+	// Validate the tasks to skip
+	for _, skippedTask := range getSkippedTasks() {
+		if taskMap[skippedTask] == nil {
+			color.Red("Task '%s' to skip does not exist.", skippedTask)
+			return 1
+		}
+	}
+	// Validate that there are no cyclic dependencies
+	if cycle := findDependencyCycle(); cycle != nil {
+		color.Red("Dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		return 1
+	}
+	// Validate that the required arguments are set and the typed arguments are valid for the tasks which will run
+	if plan, err := resolveExecutionPlan(targets...); err == nil {
+		plannedTasks := []*TaskObject{}
+		for _, entry := range plan {
+			plannedTasks = append(plannedTasks, entry.task)
+		}
+		errs := append(findMissingArguments(plannedTasks), validateArguments(plannedTasks)...)
+		if len(errs) > 0 {
+			for _, err := range errs {
+				color.Red("%v", err)
+			}
+			return 1
+		}
 	}
 
+	// Only print the execution plan on a dry run
+	if isFlagSet("dry-run") {
+		return printExecutionPlan(targets)
+	}
+
+	// Cancel the run when an interrupt or termination signal is received
+	stopSignalHandling := handleSignals()
+	defer stopSignalHandling()
+
 	// Run the setup method
-	setupErr := runLifetimeFunc("Setup", context.SetupFunc)
+	setupErr := runLifetimeFunc("Setup", lifetime.SetupFunc)
 
 	// In case of a setup error, run the teardown and exit
 	if setupErr != nil {
 		// We can ignore a possible teardown error
-		_ = runLifetimeFunc("Teardown", context.TeardownFunc)
-		return getExitCodeFromError(setupErr)
+		_ = runLifetimeFunc("Teardown", lifetime.TeardownFunc)
+		return writeReports(targets, getExitCodeFromError(setupErr))
 	}
 
-	// Run the main target only if the setup succeeded
-	taskErr := RunTarget(target)
+	// Run the main targets only if the setup succeeded
+	var taskErr error
+	for _, target := range targets {
+		if taskErr = RunTarget(target); taskErr != nil {
+			break
+		}
+	}
 
 	// Run the teardown method
-	teardownErr := runLifetimeFunc("Teardown", context.TeardownFunc)
+	teardownErr := runLifetimeFunc("Teardown", lifetime.TeardownFunc)
 
 	// Run finished
 	log.Information()
@@ -127,7 +300,13 @@ func Execute() int {
 		exitCode = getExitCodeFromError(teardownErr)
 	}
 
-	return exitCode
+	// Use the conventional exit code if the run was interrupted by a signal
+	if sig := getReceivedSignal(); sig != nil {
+		exitCode = getExitCodeFromSignal(sig)
+	}
+
+	// Write the reports
+	return writeReports(targets, exitCode)
 }
 
 // GetArgument returns the value of the argument with the given name
@@ -139,24 +318,58 @@ func GetArgument(argName string) (string, bool) {
 // GetArgumentOrDefault returns the value of the argument with the given name
 // or the given default value if the value was not present
 // and also a flag, if the argument was present or not.
+// Arguments which are not passed on the command line are read from the environment variables
+// bound with FromEnv or from GOTASKR_ARG_<NAME> and then from the config file.
 func GetArgumentOrDefault(argName string, defaultValue string) (string, bool) {
 	value, exists := argumentsMap[argName]
 	if exists {
 		return value, true
 	}
+	for _, envVar := range getArgumentEnvironmentVariables(argName) {
+		if value, exists := os.LookupEnv(envVar); exists {
+			return value, true
+		}
+	}
+	if value, exists := configArguments[argName]; exists {
+		return value, true
+	}
 	return defaultValue, false
 }
 
+// GetArgumentList returns all values of the argument with the given name.
+// The argument can be repeated on the command line and each value can contain multiple entries separated by a comma,
+// so "--tag a --tag b,c" returns a, b and c. Returns an empty list if the argument is not set.
+func GetArgumentList(argName string) []string {
+	if values, exists := argumentOccurrences[argName]; exists {
+		return argparse.SplitList(values...)
+	}
+	value, _ := GetArgument(argName)
+	return argparse.SplitList(value)
+}
+
+// GetPassthroughArgs returns all arguments after "--" exactly as they were given on the command line.
+// They can be used to forward additional arguments to a tool, for example with CustomArguments.
+func GetPassthroughArgs() []string {
+	return slices.Clone(passthroughArgs)
+}
+
+// RegisterSecret registers a value which is masked in all output of gotaskr and the tools.
+func RegisterSecret(value string) {
+	secrets.Register(value)
+}
+
 // HasArgument returns true if an argument was set and false otherwise, regardless of the value.
+// Use GetArgumentBool for flags which can be turned off with a value like "false".
 func HasArgument(argName string) bool {
 	_, exist := GetArgument(argName)
 	return exist
 }
 
 // RunTarget runs the given task and all the needed dependencies.
+// If parallel execution is enabled, the dependencies are run concurrently where possible.
 func RunTarget(target string) error {
 	var currentTask = taskMap[target]
-	currentRunningTask = currentTask
+	setCurrentRunningTask(currentTask)
 	// Early exit if the target does not exist
 	if currentTask == nil {
 		err := fmt.Errorf("target does not exist: %s", target)
@@ -168,9 +381,13 @@ func RunTarget(target string) error {
 		return currentTask.err
 	}
 	// Get the flag for exclusive runs
-	exclusive := HasArgument("exclusive") || HasArgument("e")
+	exclusive := isExclusive()
+	// Run the dependency graph in parallel if enabled
+	if !exclusive && parallelWorkers > 1 {
+		return runTargetParallel(currentTask, parallelWorkers)
+	}
 	// Run dependencies
-	if !exclusive && len(currentTask.dependencies) > 0 {
+	if !exclusive && !isExcluded(currentTask.name) && len(currentTask.dependencies) > 0 {
 		for _, dependency := range currentTask.dependencies {
 			dependencyErr := RunTarget(dependency)
 			if dependencyErr != nil {
@@ -184,22 +401,92 @@ func RunTarget(target string) error {
 		}
 	}
 
+	// Run the task itself
+	if err := runTask(currentTask); err != nil {
+		return err
+	}
+	// Run followup tasks
+	if !exclusive && currentTask.runsFollowups() && len(currentTask.followups) > 0 {
+		for _, followup := range currentTask.followups {
+			followupErr := RunTarget(followup)
+			if followupErr != nil {
+				if currentTask.deferOnError {
+					// Handle deferred errors
+					currentTask.deferredErr = followupErr
+				} else {
+					return followupErr
+				}
+			}
+		}
+	}
+	if currentTask.deferredErr != nil {
+		return currentTask.deferredErr
+	}
+	return nil
+}
+
+// runTask runs a single task together with the task lifetime methods and records the run.
+func runTask(currentTask *TaskObject) error {
+	// Do not start any new task if the run was interrupted
+	if runCtx.Err() != nil {
+		return fmt.Errorf("run was interrupted: %w", context.Canceled)
+	}
+
+	// Skip the task if requested or a criteria is not fulfilled
+	if reason, skip := currentTask.shouldSkip(); skip {
+		currentTask.didRun = true
+		currentTask.skipped = true
+		currentTask.skipReason = reason
+		currentTask.startTime = time.Now()
+		runMutex.Lock()
+		taskRun = append(taskRun, currentTask)
+		runMutex.Unlock()
+		printTaskHeader(currentTask.name)
+		printTaskFooter(currentTask)
+		return nil
+	}
+
+	// Skip the task if the inputs and outputs did not change since the last successful run
+	if currentTask.usesFingerprint() {
+		upToDate, err := currentTask.isUpToDate()
+		if err != nil {
+			color.Yellow("Failed to check if '%s' is up-to-date: %v", currentTask.name, err)
+		}
+		if upToDate {
+			currentTask.didRun = true
+			currentTask.upToDate = true
+			currentTask.startTime = time.Now()
+			runMutex.Lock()
+			taskRun = append(taskRun, currentTask)
+			runMutex.Unlock()
+			printTaskHeader(currentTask.name)
+			printTaskFooter(currentTask)
+			return nil
+		}
+	}
+
 	// Run the task setup method
-	setupErr := runLifetimeFunc("TaskSetup", context.TaskSetupFunc)
+	setupErr := runLifetimeFunc("TaskSetup", lifetime.TaskSetupFunc)
 
 	// In case of a setup error, run the teardown and exit
 	if setupErr != nil {
 		// We can ignore a possible teardown error
-		_ = runLifetimeFunc("TaskTeardown", context.TaskTeardownFunc)
+		_ = runLifetimeFunc("TaskTeardown", lifetime.TaskTeardownFunc)
 		return setupErr
 	}
 
 	// Run the task itself
-	currentRunningTask = currentTask
-	printTaskHeader(target)
+	setCurrentRunningTask(currentTask)
+	printTaskHeader(currentTask.name)
 	start := time.Now()
-	taskErr := runTaskFunc(currentTask)
+	taskErr := runTaskFuncWithRetries(currentTask)
 	elapsed := time.Since(start)
+	// Remember the fingerprint of a successful run for the up-to-date check
+	if currentTask.usesFingerprint() {
+		if err := currentTask.updateFingerprint(taskErr == nil); err != nil {
+			color.Yellow("Failed to update the fingerprint of '%s': %v", currentTask.name, err)
+		}
+	}
 	// Handle error deferring
 	if taskErr != nil && currentTask.deferOnError {
 		currentTask.deferredErr = taskErr
@@ -211,47 +498,30 @@ func RunTarget(target string) error {
 		taskErr = nil
 	}
 	currentTask.didRun = true
+	currentTask.startTime = start
 	currentTask.duration = elapsed
 	currentTask.err = taskErr
+	runMutex.Lock()
 	taskRun = append(taskRun, currentTask)
+	runMutex.Unlock()
 	printTaskFooter(currentTask)
 
 	// Run the task teardown method
-	teardownErr := runLifetimeFunc("TaskTeardown", context.TaskTeardownFunc)
+	teardownErr := runLifetimeFunc("TaskTeardown", lifetime.TaskTeardownFunc)
 
 	// If the teardown failed but nothing else, still fail with the teardown error
 	if teardownErr != nil && taskErr == nil {
 		return teardownErr
 	}
-
-	// Abort execution if the task failed
-	if taskErr != nil {
-		return taskErr
-	}
-	// Run followup tasks
-	if !exclusive && len(currentTask.followups) > 0 {
-		for _, followup := range currentTask.followups {
-			followupErr := RunTarget(followup)
-			if followupErr != nil {
-				if currentTask.deferOnError {
-					// Handle deferred errors
-					currentTask.deferredErr = followupErr
-				} else {
-					return followupErr
-				}
-			}
-		}
-	}
-	if currentTask.deferredErr != nil {
-		return currentTask.deferredErr
-	}
-	return nil
+	return taskErr
 }
 
 func runLifetimeFunc(lifetimeStage string, function func() error) error {
 	if function == nil {
 		return nil
 	}
+	lifetimeMutex.Lock()
+	defer lifetimeMutex.Unlock()
 	log.Informationf("--- %s %s", lifetimeStage, strings.Repeat("-", 60-5-len(lifetimeStage)))
 	err := runFuncRecover(function)
 	if err != nil {
@@ -261,8 +531,136 @@ func runLifetimeFunc(lifetimeStage string, function func() error) error {
 	return nil
 }
 
-func runTaskFunc(currentTask *TaskObject) (err error) {
-	return runFuncRecover(currentTask.taskFunc)
+// runTaskFuncWithRetries runs the function of the task and retries it if the task has a retry policy.
+func runTaskFuncWithRetries(currentTask *TaskObject) error {
+	maxAttempts := max(currentTask.retryAttempts, 1)
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		runMutex.Lock()
+		currentTask.attempts = attempt
+		runMutex.Unlock()
+		err = runTaskFunc(currentTask)
+		if err == nil {
+			return nil
+		}
+		// Do not retry if the run was interrupted
+		if runCtx.Err() != nil || errors.Is(err, context.Canceled) {
+			return err
+		}
+		if maxAttempts > 1 {
+			color.Red("Attempt %d/%d failed: %v", attempt, maxAttempts, err)
+			if attempt < maxAttempts {
+				log.Informationf("Retrying in %s", currentTask.retryBackoff)
+				select {
+				case <-time.After(currentTask.retryBackoff):
+				case <-runCtx.Done():
+					return err
+				}
+			}
+		}
+	}
+	return err
+}
+
+// runTaskFunc runs the function of the task with a context which is cancelled when the timeout is reached
+// or the run is interrupted. The context is also used by the tools, so their processes are killed when it is cancelled.
+// The function is always waited for, so functions which do not respect the context still finish before the next task starts.
+func runTaskFunc(currentTask *TaskObject) error {
+	timeout := goext.Ternary(currentTask.timeout > 0, currentTask.timeout, defaultTimeout)
+	ctx, cancel := context.WithCancel(runCtx)
+	defer cancel()
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+	}
+
+	// Inform that a cancelled function is still running
+	notified := make(chan struct{})
+	stopNotification := context.AfterFunc(ctx, func() {
+		defer close(notified)
+		color.Yellow("Task '%s' was cancelled, waiting for it to stop", currentTask.name)
+	})
+	ctx = context.WithValue(ctx, runningTaskKey{}, currentTask)
+	// Let the tools use the context of a task which runs alone.
+	// Tasks which run in parallel must pass their context to the tools explicitly.
+	runsAlone := parallelWorkers <= 1 || !currentTask.parallelizable
+	if runsAlone {
+		toolctx.Set(ctx)
+	}
+	err := runFuncRecover(func() error {
+		return currentTask.taskFunc(ctx)
+	})
+	if runsAlone {
+		toolctx.Set(context.Background())
+	}
+	if !stopNotification() {
+		// Wait until the notification is written
+		<-notified
+	}
+
+	if runCtx.Err() != nil {
+		return fmt.Errorf("task was interrupted: %w", context.Canceled)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("task timed out after %s: %w", timeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// handleSignals cancels the current run when an interrupt or termination signal is received.
+// This cancels the running tasks and kills the processes of the tools started by them.
+// A second signal is not handled anymore and terminates the process immediately.
+// Returns a function to stop the signal handling.
+func handleSignals() func() {
+	runCtx, cancelRun = context.WithCancel(context.Background())
+	setReceivedSignal(nil)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			color.Red("Received %s, cancelling the run", sig)
+			setReceivedSignal(sig)
+			cancelRun()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+func setCurrentRunningTask(task *TaskObject) {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	currentRunningTask = task
+}
+
+// getRunningTask gets the task from the given context of a task.
+// Falls back to the last started task if the context does not belong to a task.
+func getRunningTask(ctx context.Context) *TaskObject {
+	if task, ok := ctx.Value(runningTaskKey{}).(*TaskObject); ok {
+		return task
+	}
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	return currentRunningTask
+}
+
+func setReceivedSignal(sig os.Signal) {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	receivedSignal = sig
+}
+
+func getReceivedSignal() os.Signal {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	return receivedSignal
 }
 
 func runFuncRecover(function func() error) (err error) {
@@ -277,6 +675,14 @@ func runFuncRecover(function func() error) (err error) {
 
 // Task registers the given function with the name so it can be executed.
 func Task(name string, taskFunc func() error) *TaskObject {
+	return TaskWithContext(name, func(ctx context.Context) error {
+		return taskFunc()
+	})
+}
+
+// TaskWithContext registers the given function with the name so it can be executed.
+// The function gets a context which is cancelled when the task times out or the run is interrupted.
+func TaskWithContext(name string, taskFunc func(ctx context.Context) error) *TaskObject {
 	task := TaskObject{}
 	task.name = name
 	task.taskFunc = taskFunc
@@ -286,25 +692,36 @@ func Task(name string, taskFunc func() error) *TaskObject {
 }
 
 func Setup(setupFunc func() error) {
-	context.SetupFunc = setupFunc
+	lifetime.SetupFunc = setupFunc
 }
 
 func Teardown(taskFunc func() error) {
-	context.TeardownFunc = taskFunc
+	lifetime.TeardownFunc = taskFunc
 }
 
 func TaskSetup(taskFunc func() error) {
-	context.TaskSetupFunc = taskFunc
+	lifetime.TaskSetupFunc = taskFunc
 }
 
 func TaskTeardown(taskFunc func() error) {
-	context.TaskTeardownFunc = taskFunc
+	lifetime.TaskTeardownFunc = taskFunc
 }
 
 type argument struct {
-	name        string
-	description string
-	optional    bool
+	name          string
+	description   string
+	optional      bool
+	argType       argumentType // The type of the value.
+	defaultValue  string       // The default value if the argument is not set.
+	allowedValues []string     // The allowed values of an enum argument.
+	defaultErr    error        // The error (if any) of the default value which is not allowed.
+	envVar        string       // The environment variable to read the value from if the argument is not set.
+	secret        bool         // A flag to indicate if the value is masked in the output.
+}
+
+type taskCriteria struct {
+	condition func() bool
+	reason    string
 }
 
 type gotaskrContext struct {
@@ -318,24 +735,38 @@ type TimeMeasurement struct {
 	name      string
 	startTime time.Time
 	duration  time.Duration
+	attempt   int
 }
 
 // TaskObject represents a registered task.
 type TaskObject struct {
-	name             string        // The name of the task.
-	description      string        // The description of the task.
-	arguments        []argument    // The arguments of the task.
-	taskFunc         func() error  // The function of the task.
-	dependencies     []string      // A list of dependency tasks.
-	dependees        []string      // A list of dependee tasks.
-	followups        []string      // A list of followup tasks.
-	continueOnError  bool          // A flag to indicate if the run should continue when an error occurred.
-	deferOnError     bool          // A flag to indicate if the error should be deferred until the end.
-	didRun           bool          // A flag to indicate if the task did already run.
-	duration         time.Duration // A runtime duration of the task if it ran already.
-	err              error         // The error (if any) of the task when it ran.
-	ignoredErr       error         // The error (if any) which is ignored.
-	deferredErr      error         // The deferred error (if any) of the task when it ran.
+	name             string                          // The name of the task.
+	description      string                          // The description of the task.
+	arguments        []argument                      // The arguments of the task.
+	criterias        []taskCriteria                  // The criterias which need to be fulfilled for the task to run.
+	taskFunc         func(ctx context.Context) error // The function of the task.
+	timeout          time.Duration                   // The timeout of the task.
+	retryAttempts    int                             // The maximum number of attempts to run the task.
+	retryBackoff     time.Duration                   // The time to wait between the attempts.
+	dependencies     []string                        // A list of dependency tasks.
+	dependees        []string                        // A list of dependee tasks.
+	followups        []string                        // A list of followup tasks.
+	continueOnError  bool                            // A flag to indicate if the run should continue when an error occurred.
+	deferOnError     bool                            // A flag to indicate if the error should be deferred until the end.
+	parallelizable   bool                            // A flag to indicate if the task can run in parallel with other tasks.
+	tags             []string                        // The tags of the task.
+	didRun           bool                            // A flag to indicate if the task did already run.
+	skipped          bool                            // A flag to indicate if the task was skipped because of a criteria.
+	skipReason       string                          // The reason why the task was skipped.
+	upToDate         bool                            // A flag to indicate if the task was skipped because it is up-to-date.
+	inputs           []string                        // The glob patterns of the input files of the task.
+	outputs          []string                        // The glob patterns of the output files of the task.
+	attempts         int                             // The number of attempts the task needed.
+	startTime        time.Time                       // The time when the task started if it ran already.
+	duration         time.Duration                   // A runtime duration of the task if it ran already.
+	err              error                           // The error (if any) of the task when it ran.
+	ignoredErr       error                           // The error (if any) which is ignored.
+	deferredErr      error                           // The deferred error (if any) of the task when it ran.
 	timeMeasurements []*TimeMeasurement
 }
 
@@ -428,6 +859,98 @@ func (taskObject *TaskObject) DeferOnError() *TaskObject {
 	return taskObject
 }
 
+// Parallelizable marks the task as safe to run concurrently with other parallelizable tasks.
+// This only has an effect if the parallel mode is enabled with the "parallel" argument.
+// The functions registered with TaskSetup and TaskTeardown are still called one at a time.
+// Such tasks should be registered with TaskWithContext and must pass the context explicitly to the tools
+// (ToolSettingsBase.WithContext) and to MeasureTimeWithContext, StartTimeMeasurementWithContext and AddFollowupTaskWithContext.
+func (taskObject *TaskObject) Parallelizable() *TaskObject {
+	taskObject.parallelizable = true
+	return taskObject
+}
+
+// Tags adds the given tags to the task. Duplicate tags are removed.
+// All tasks with a tag can be run with the "tags" argument.
+func (taskObject *TaskObject) Tags(tags ...string) *TaskObject {
+	for _, tag := range tags {
+		taskObject.tags = goext.SliceAppendIfMissing(taskObject.tags, tag)
+	}
+	return taskObject
+}
+
+// Timeout sets the maximum duration the task is allowed to run.
+// The context of the task is cancelled when the timeout is reached and the task fails.
+// This also kills the processes of the tools started by the task.
+// A task which does not respect the context is waited for until it returns.
+func (taskObject *TaskObject) Timeout(timeout time.Duration) *TaskObject {
+	taskObject.timeout = timeout
+	return taskObject
+}
+
+// Retry sets the maximum number of attempts to run the task and the time to wait between them.
+func (taskObject *TaskObject) Retry(attempts int, backoff time.Duration) *TaskObject {
+	taskObject.retryAttempts = attempts
+	taskObject.retryBackoff = backoff
+	return taskObject
+}
+
+// Inputs adds glob patterns of files the task uses. The pattern "**" matches any number of directories.
+// The task is skipped if its inputs and outputs did not change since the last successful run.
+func (taskObject *TaskObject) Inputs(globs ...string) *TaskObject {
+	taskObject.inputs = append(taskObject.inputs, globs...)
+	return taskObject
+}
+
+// Outputs adds glob patterns of files the task produces. The pattern "**" matches any number of directories.
+// The task is skipped if its inputs and outputs did not change since the last successful run.
+func (taskObject *TaskObject) Outputs(globs ...string) *TaskObject {
+	taskObject.outputs = append(taskObject.outputs, globs...)
+	return taskObject
+}
+
+// WithCriteria adds a criteria which needs to be fulfilled for the task to run.
+// If the criteria is not fulfilled, the task is skipped but dependent tasks still run.
+func (taskObject *TaskObject) WithCriteria(criteria func() bool) *TaskObject {
+	return taskObject.WithCriteriaMsg(criteria, "criteria not fulfilled")
+}
+
+// WithCriteriaMsg adds a criteria which needs to be fulfilled for the task to run.
+// The reason is shown when the task is skipped because of the criteria.
+func (taskObject *TaskObject) WithCriteriaMsg(criteria func() bool, reason string) *TaskObject {
+	taskObject.criterias = append(taskObject.criterias, taskCriteria{
+		condition: criteria,
+		reason:    reason,
+	})
+	return taskObject
+}
+
+// shouldSkip checks if the task should be skipped because it was excluded or a criteria is not fulfilled.
+// Returns the reason for skipping.
+func (taskObject *TaskObject) shouldSkip() (string, bool) {
+	if reason, excluded := getExclusionReason(taskObject.name); excluded {
+		return reason, true
+	}
+	reason, fulfilled := taskObject.checkCriteria()
+	return reason, !fulfilled
+}
+
+// runsFollowups checks if the followups should run after the task.
+// Followups of skipped tasks do not run, except if the task was skipped because it succeeded in the previous run.
+func (taskObject *TaskObject) runsFollowups() bool {
+	return !taskObject.skipped || (rerunSucceededTasks[taskObject.name] && !isSkippedByArgument(taskObject.name))
+}
+
+// checkCriteria checks all criterias of the task.
+// Returns the reason of the first criteria which is not fulfilled.
+func (taskObject *TaskObject) checkCriteria() (string, bool) {
+	for _, criteria := range taskObject.criterias {
+		if !criteria.condition() {
+			return criteria.reason, false
+		}
+	}
+	return "", true
+}
+
 // Description sets the description of a task. Will be shown when the help is displayed.
 func (taskObject *TaskObject) Description(description string) *TaskObject {
 	taskObject.description = description
@@ -435,6 +958,7 @@ func (taskObject *TaskObject) Description(description string) *TaskObject {
 }
 
 // Argument adds a description for an argument. Will be shown when the help is displayed.
+// Arguments which are not optional must be set when the task is part of the run.
 func (taskObject *TaskObject) Argument(argumentName string, argumentDescription string, optional bool) *TaskObject {
 	newArgument := argument{
 		name:        argumentName,
@@ -446,32 +970,63 @@ func (taskObject *TaskObject) Argument(argumentName string, argumentDescription
 }
 
 // AddFollowupTask allows adding one or more tasks that should run after the current finished.
+// Tasks which run in parallel must use AddFollowupTaskWithContext.
 func AddFollowupTask(taskName ...string) {
-	currentRunningTask.Then(taskName...)
+	AddFollowupTaskWithContext(context.Background(), taskName...)
+}
+
+// AddFollowupTaskWithContext allows adding one or more tasks that should run after the task of the given context finished.
+func AddFollowupTaskWithContext(ctx context.Context, taskName ...string) {
+	runningTask := getRunningTask(ctx)
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	runningTask.Then(taskName...)
 }
 
+// MeasureTime measures the duration of the given function and adds it to the running task.
+// Tasks which run in parallel must use MeasureTimeWithContext.
 func MeasureTime(measurementName string, f func() error) error {
+	return MeasureTimeWithContext(context.Background(), measurementName, f)
+}
+
+// MeasureTimeWithContext measures the duration of the given function and adds it to the task of the given context.
+func MeasureTimeWithContext(ctx context.Context, measurementName string, f func() error) error {
 	// Execute the function
 	start := time.Now()
 	err := f()
 	elapsed := time.Since(start)
 
 	// Add the time measurement
-	currentRunningTask.timeMeasurements = append(currentRunningTask.timeMeasurements, &TimeMeasurement{
+	runningTask := getRunningTask(ctx)
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	runningTask.timeMeasurements = append(runningTask.timeMeasurements, &TimeMeasurement{
 		name:      measurementName,
 		startTime: start,
 		duration:  elapsed,
+		attempt:   runningTask.attempts,
 	})
 
 	return err
 }
 
+// StartTimeMeasurement starts a time measurement for the running task which is stopped with Finish.
+// Tasks which run in parallel must use StartTimeMeasurementWithContext.
 func StartTimeMeasurement(measurementName string) *TimeMeasurement {
+	return StartTimeMeasurementWithContext(context.Background(), measurementName)
+}
+
+// StartTimeMeasurementWithContext starts a time measurement for the task of the given context which is stopped with Finish.
+func StartTimeMeasurementWithContext(ctx context.Context, measurementName string) *TimeMeasurement {
+	runningTask := getRunningTask(ctx)
+	runMutex.Lock()
+	defer runMutex.Unlock()
 	newItem := &TimeMeasurement{
 		name:      measurementName,
 		startTime: time.Now(),
+		attempt:   runningTask.attempts,
 	}
-	currentRunningTask.timeMeasurements = append(currentRunningTask.timeMeasurements, newItem)
+	runningTask.timeMeasurements = append(runningTask.timeMeasurements, newItem)
 	return newItem
 }
 
@@ -491,29 +1046,218 @@ func (t *TimeMeasurement) Duration() time.Duration {
 	return t.duration
 }
 
-func printTasks() {
-	log.Information("Please specify one of the following targets:")
-	var sb strings.Builder
+// findDependencyCycle searches for cyclic dependencies between the tasks.
+// Returns the path of the first cycle found (with the first task repeated at the end) or nil if there is none.
+func findDependencyCycle() []string {
+	visited := map[string]bool{}
+	stack := []string{}
+	onStack := map[string]bool{}
+
+	var visit func(taskName string) []string
+	visit = func(taskName string) []string {
+		if onStack[taskName] {
+			// Found a cycle, extract it from the stack
+			start := slices.Index(stack, taskName)
+			return append(slices.Clone(stack[start:]), taskName)
+		}
+		if visited[taskName] {
+			return nil
+		}
+		visited[taskName] = true
+		onStack[taskName] = true
+		stack = append(stack, taskName)
+		for _, dependency := range taskMap[taskName].dependencies {
+			if taskMap[dependency] == nil {
+				continue
+			}
+			if cycle := visit(dependency); cycle != nil {
+				return cycle
+			}
+		}
+		stack = stack[:len(stack)-1]
+		onStack[taskName] = false
+		return nil
+	}
+
 	for _, taskName := range taskList {
-		task := taskMap[taskName]
-		fmt.Fprintf(&sb, "- %s", task.name)
-		sb.WriteString(log.Newline)
-		if task.description != "" {
-			lines := goext.StringSplitByNewLine(task.description)
-			for _, line := range lines {
-				fmt.Fprintf(&sb, "  %s", line)
-				sb.WriteString(log.Newline)
+		if cycle := visit(taskName); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// getSkippedTasks gets the tasks to skip from the "skip" argument.
+// Multiple tasks can be separated by a comma.
+func getSkippedTasks() []string {
+	return GetArgumentList("skip")
+}
+
+// isSkippedByArgument checks if the given task was excluded with the "skip" argument.
+func isSkippedByArgument(taskName string) bool {
+	return slices.Contains(getSkippedTasks(), taskName)
+}
+
+// getExclusionReason checks if the given task is excluded from the run because it was skipped with the "skip" argument
+// or it succeeded in the previous run when rerunning the failed tasks. Returns the reason for the exclusion.
+func getExclusionReason(taskName string) (string, bool) {
+	if isSkippedByArgument(taskName) {
+		return "excluded with --skip", true
+	}
+	if rerunSucceededTasks[taskName] {
+		return "succeeded in the previous run", true
+	}
+	return "", false
+}
+
+// isExcluded checks if the given task is excluded from the run. Excluded tasks do not need their dependencies.
+func isExcluded(taskName string) bool {
+	_, excluded := getExclusionReason(taskName)
+	return excluded
+}
+
+// getTargets gets the targets to run from the "target" argument.
+// Multiple targets can be separated by a comma or given by repeating the argument.
+// A target ending with '*' selects all tasks starting with the given prefix,
+// so "Namespace:*" selects all tasks within the namespace.
+func getTargets() []string {
+	targets := []string{}
+	for _, target := range GetArgumentList("target") {
+		if !strings.HasSuffix(target, "*") {
+			targets = goext.SliceAppendIfMissing(targets, target)
+			continue
+		}
+		prefix := strings.TrimSuffix(target, "*")
+		matched := false
+		for _, taskName := range taskList {
+			if strings.HasPrefix(taskName, prefix) {
+				targets = goext.SliceAppendIfMissing(targets, taskName)
+				matched = true
 			}
 		}
-		if len(task.arguments) > 0 {
-			fmt.Fprintln(&sb, "  Arguments:")
-			for _, arg := range task.arguments {
-				fmt.Fprintf(&sb, "    %s: %s%s", arg.name, arg.description, goext.Ternary(arg.optional, " (optional)", ""))
+		if !matched {
+			// Keep the target so it is reported as not existing
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// getTasksWithTags gets the names of all tasks which have any of the given tags in registration order.
+func getTasksWithTags(tags []string) []string {
+	taskNames := []string{}
+	for _, taskName := range taskList {
+		if slices.ContainsFunc(taskMap[taskName].tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			taskNames = append(taskNames, taskName)
+		}
+	}
+	return taskNames
+}
+
+// isExclusive returns true if only the target should run, without dependencies and followups.
+func isExclusive() bool {
+	return isFlagSet("exclusive", "e")
+}
+
+// getParallelWorkers gets the number of workers from the "parallel" argument.
+// If the argument has no value, the number of CPUs is used.
+func getParallelWorkers() (int, error) {
+	value, exists := GetArgument("parallel")
+	if !exists {
+		return 1, nil
+	}
+	if value == "" {
+		return runtime.NumCPU(), nil
+	}
+	workers, err := strconv.Atoi(value)
+	if err != nil || workers < 1 {
+		return 0, fmt.Errorf("invalid value for parallel: %s", value)
+	}
+	return workers, nil
+}
+
+func printTasks(header string) {
+	log.Information(header)
+	log.Information(formatTaskList())
+}
+
+// taskTreeNode is a namespace or task within the tree of tasks.
+type taskTreeNode struct {
+	name     string          // The name of the namespace or task without its parent namespaces.
+	task     *TaskObject     // The task (if any) with the full name of the node.
+	children []*taskTreeNode // The tasks and namespaces within the namespace.
+}
+
+// buildTaskTree builds the tree of all tasks where the namespaces are separated by ':'.
+// The nodes are ordered by their first registration.
+func buildTaskTree() *taskTreeNode {
+	root := &taskTreeNode{}
+	for _, taskName := range taskList {
+		node := root
+		for _, segment := range strings.Split(taskName, ":") {
+			index := slices.IndexFunc(node.children, func(child *taskTreeNode) bool { return child.name == segment })
+			if index < 0 {
+				node.children = append(node.children, &taskTreeNode{name: segment})
+				index = len(node.children) - 1
+			}
+			node = node.children[index]
+		}
+		node.task = taskMap[taskName]
+	}
+	return root
+}
+
+// formatTaskList formats the tree of tasks with their descriptions and arguments followed by the tags.
+func formatTaskList() string {
+	var sb strings.Builder
+	var writeNodes func(nodes []*taskTreeNode, indent string)
+	writeNodes = func(nodes []*taskTreeNode, indent string) {
+		for _, node := range nodes {
+			if node.task == nil {
+				fmt.Fprintf(&sb, "%s- %s:", indent, node.name)
+				sb.WriteString(log.Newline)
+				writeNodes(node.children, indent+"  ")
+				continue
+			}
+			fmt.Fprintf(&sb, "%s- %s", indent, node.name)
+			sb.WriteString(log.Newline)
+			if node.task.description != "" {
+				lines := goext.StringSplitByNewLine(node.task.description)
+				for _, line := range lines {
+					fmt.Fprintf(&sb, "%s  %s", indent, line)
+					sb.WriteString(log.Newline)
+				}
+			}
+			if len(node.task.arguments) > 0 {
+				fmt.Fprintf(&sb, "%s  Arguments:", indent)
 				sb.WriteString(log.Newline)
+				for _, arg := range node.task.arguments {
+					fmt.Fprintf(&sb, "%s    %s: %s%s", indent, arg.name, arg.description, arg.formatDetails())
+					sb.WriteString(log.Newline)
+				}
 			}
+			writeNodes(node.children, indent+"  ")
 		}
 	}
-	log.Information(sb.String())
+	writeNodes(buildTaskTree().children, "")
+
+	// Group the tasks by their tags
+	tags := []string{}
+	for _, taskName := range taskList {
+		for _, tag := range taskMap[taskName].tags {
+			tags = goext.SliceAppendIfMissing(tags, tag)
+		}
+	}
+	if len(tags) > 0 {
+		sb.WriteString(log.Newline)
+		sb.WriteString("Tags (run with --tags):")
+		sb.WriteString(log.Newline)
+		for _, tag := range tags {
+			fmt.Fprintf(&sb, "- %s: %s", tag, strings.Join(getTasksWithTags([]string{tag}), ", "))
+			sb.WriteString(log.Newline)
+		}
+	}
+	return sb.String()
 }
 
 func printArguments() {
@@ -533,6 +1277,10 @@ func printArguments() {
 		sb.WriteString(log.Newline)
 		log.Debug(sb.String())
 	}
+	if len(passthroughArgs) > 0 {
+		log.Debugf("Passthrough arguments: %s", strings.Join(passthroughArgs, " "))
+		log.Debug()
+	}
 }
 
 func printTaskHeader(taskName string) {
@@ -541,7 +1289,13 @@ func printTaskHeader(taskName string) {
 
 func printTaskFooter(task *TaskObject) {
 	log.Informationf("=== /%s %s", task.name, strings.Repeat("=", 60-5-1-len(task.name)))
-	log.Informationf("Duration: %s", formatDuration(task.duration))
+	if task.skipped {
+		log.Informationf("Skipped: %s", task.skipReason)
+	} else if task.upToDate {
+		log.Information("Up-to-date")
+	} else {
+		log.Informationf("Duration: %s", formatDuration(task.duration))
+	}
 	printTaskError(task, false)
 }
 
@@ -568,8 +1322,15 @@ func printTaskRuns() {
 	log.Information(strings.Repeat("-", 80))
 	totalDuration := time.Duration(0)
 	for _, run := range taskRun {
-		text := fmt.Sprintf("%-50s%-13d%-17s", run.name, getExitCodeFromTaskRun(run), formatDuration(run.duration))
-		if run.err != nil || run.deferredErr != nil {
+		runName := goext.Ternary(run.attempts > 1, fmt.Sprintf("%s (%d attempts)", run.name, run.attempts), run.name)
+		text := fmt.Sprintf("%-50s%-13d%-17s", runName, getExitCodeFromTaskRun(run), formatDuration(run.duration))
+		if run.skipped {
+			text = fmt.Sprintf("%-50s%-13s%s", runName, "-", fmt.Sprintf("Skipped (%s)", run.skipReason))
+			color.Yellow(text)
+		} else if run.upToDate {
+			text = fmt.Sprintf("%-50s%-13d%s", runName, 0, "Up-to-date")
+			log.Information(text)
+		} else if run.err != nil || run.deferredErr != nil {
 			color.Red(text)
 
 		} else {
@@ -578,7 +1339,8 @@ func printTaskRuns() {
 		color.Set(color.FgWhite)
 		for i, measurement := range run.timeMeasurements {
 			prefix := goext.Ternary(i == len(run.timeMeasurements)-1, "└─", "├─")
-			measurementText := fmt.Sprintf("%s %-60s%-17s", prefix, measurement.name, formatDuration(measurement.duration))
+			measurementName := goext.Ternary(run.attempts > 1, fmt.Sprintf("%s (attempt %d)", measurement.name, measurement.attempt), measurement.name)
+			measurementText := fmt.Sprintf("%s %-60s%-17s", prefix, measurementName, formatDuration(measurement.duration))
 			log.Information(measurementText)
 		}
 		color.Set(color.FgGreen)
@@ -607,6 +1369,14 @@ func getExitCodeFromTaskRun(run *TaskObject) int {
 	return 0
 }
 
+// getExitCodeFromSignal gets the conventional exit code (128 + signal number) for the given signal.
+func getExitCodeFromSignal(sig os.Signal) int {
+	if sysSig, ok := sig.(syscall.Signal); ok {
+		return 128 + int(sysSig)
+	}
+	return 130
+}
+
 func getExitCodeFromError(err error) int {
 	if err != nil {
 		if ierr, ok := err.(*exec.ExitError); ok {
@@ -625,8 +1395,15 @@ func clear() {
 	taskMap = map[string]*TaskObject{}
 	taskList = []string{}
 	taskRun = []*TaskObject{}
-	context.SetupFunc = nil
-	context.TeardownFunc = nil
-	context.TaskSetupFunc = nil
-	context.TaskTeardownFunc = nil
+	parallelWorkers = 1
+	defaultTimeout = 0
+	rerunSucceededTasks = map[string]bool{}
+	secrets.Clear()
+	configArguments = map[string]string{}
+	argumentOccurrences = map[string][]string{}
+	passthroughArgs = []string{}
+	lifetime.SetupFunc = nil
+	lifetime.TeardownFunc = nil
+	lifetime.TaskSetupFunc = nil
+	lifetime.TaskTeardownFunc = nil
 }
@@ -1,15 +1,41 @@
 package gotaskr
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/roemer/goext"
+	"github.com/roemer/gotaskr/argparse"
+	"github.com/roemer/gotaskr/gttools"
+	"github.com/roemer/gotaskr/internal/toolctx"
+	"github.com/roemer/gotaskr/log"
 	"github.com/stretchr/testify/assert"
 )
 
+func TestMain(m *testing.M) {
+	// Persist the state of the runs outside of the source tree
+	stateDirectory, err := os.MkdirTemp("", "gotaskr-state-")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defaultStateDirectory = stateDirectory
+	exitCode := m.Run()
+	os.RemoveAll(stateDirectory)
+	os.Exit(exitCode)
+}
+
 func TestNoErrorTask(t *testing.T) {
 	assert := assert.New(t)
 
@@ -370,26 +396,1910 @@ func TestLifeTimeTaskSetupAndTaskTeardownError(t *testing.T) {
 	assert.False(taskCalled)
 }
 
-////////////////////
-// Helpers
-////////////////////
+func TestParallelDependencies(t *testing.T) {
+	assert := assert.New(t)
 
-func getExitError(errorCode int) error {
-	return goext.CmdRunners.Default.Run(getBashPath(), "-c", fmt.Sprintf("exit %d", errorCode))
+	// Prepare
+	clear()
+	var started sync.WaitGroup
+	started.Add(2)
+	waitForOthers := func() error {
+		started.Done()
+		done := make(chan struct{})
+		go func() { started.Wait(); close(done) }()
+		select {
+		case <-done:
+			return nil
+		case <-time.After(5 * time.Second):
+			return fmt.Errorf("tasks did not run in parallel")
+		}
+	}
+	task1 := Task("Test1", waitForOthers).Parallelizable()
+	task2 := Task("Test2", waitForOthers).Parallelizable()
+	taskAll := Task("All", Noop).DependsOn(task1.name, task2.name)
+	argumentsMap = map[string]string{"target": taskAll.name, "parallel": "2"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal(3, len(taskRun))
+	assert.Equal(taskAll, taskRun[2])
 }
 
-func getBashPath() string {
-	if runtime.GOOS == "windows" {
-		return `C:\Program Files\Git\bin\bash.exe`
+func TestParallelDependencyErrorWithDefer(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	desiredExitCode := 10
+	followupCalled := false
+	task1 := Task("Test1", Noop).Parallelizable()
+	task2 := Task("Test2", func() error { return getExitError(desiredExitCode) }).Parallelizable()
+	task3 := Task("Test3", Noop).Parallelizable().Then("Followup")
+	Task("Followup", func() error { followupCalled = true; return nil })
+	taskAll := Task("All", Noop).DependsOn(task1.name, task2.name, task3.name).DeferOnError()
+	argumentsMap = map[string]string{"target": taskAll.name, "parallel": "3"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(desiredExitCode, exitCode)
+	assert.Equal(5, len(taskRun))
+	assert.True(followupCalled)
+	assertExitError(assert, task2.err, desiredExitCode)
+	assertExitError(assert, taskAll.deferredErr, desiredExitCode)
+}
+
+func TestParallelDependencyErrorWithoutDefer(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	desiredExitCode := 10
+	task1 := Task("Test1", func() error { return getExitError(desiredExitCode) })
+	task2 := Task("Test2", Noop)
+	taskAll := Task("All", Noop).DependsOn(task1.name, task2.name)
+	argumentsMap = map[string]string{"target": taskAll.name, "parallel": "2"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(desiredExitCode, exitCode)
+	assert.Equal(1, len(taskRun))
+	assertExitError(assert, task1.err, desiredExitCode)
+	assert.False(taskAll.didRun)
+}
+
+func TestParallelTimeMeasurements(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	measure := func(ctx context.Context) error {
+		measurement := StartTimeMeasurementWithContext(ctx, "Start")
+		err := MeasureTimeWithContext(ctx, "Measure", func() error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		})
+		measurement.Finish()
+		return err
 	}
-	return "/bin/bash"
+	taskA := TaskWithContext("A", measure).Parallelizable()
+	taskB := TaskWithContext("B", measure).Parallelizable()
+	taskC := TaskWithContext("C", measure).Parallelizable()
+	taskAll := Task("All", Noop).DependsOn(taskA.name, taskB.name, taskC.name)
+	argumentsMap = map[string]string{"target": taskAll.name, "parallel": "3"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	for _, task := range []*TaskObject{taskA, taskB, taskC} {
+		assert.Equal(2, len(task.timeMeasurements), task.name)
+	}
+	assert.Equal(0, len(taskAll.timeMeasurements))
 }
 
-func assertExitError(assert *assert.Assertions, err error, exitCode int) {
-	assert.NotNil(err)
-	if err != nil {
-		ierr, isError := err.(*exec.ExitError)
-		assert.True(isError)
-		assert.Equal(exitCode, ierr.ExitCode())
+func TestToolContext(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	var mutex sync.Mutex
+	usesTaskContext := map[string]bool{}
+	check := func(ctx context.Context) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		usesTaskContext[getRunningTask(ctx).name] = toolctx.Get() == ctx
+		return nil
+	}
+	taskA := TaskWithContext("A", check).Parallelizable()
+	taskB := TaskWithContext("B", check).Parallelizable()
+	taskAll := TaskWithContext("All", check).DependsOn(taskA.name, taskB.name)
+	argumentsMap = map[string]string{"target": taskAll.name, "parallel": "2"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal(map[string]bool{"A": false, "B": false, "All": true}, usesTaskContext)
+	assert.Equal(context.Background(), toolctx.Get())
+}
+
+func TestParallelLifetimeFunctions(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	running := 0
+	maxRunning := 0
+	calls := 0
+	lifetimeFunc := func() error {
+		// Not guarded on purpose as the calls must not overlap
+		running++
+		maxRunning = max(maxRunning, running)
+		calls++
+		time.Sleep(10 * time.Millisecond)
+		running--
+		return nil
+	}
+	TaskSetup(lifetimeFunc)
+	TaskTeardown(lifetimeFunc)
+	taskA := Task("A", Noop).Parallelizable()
+	taskB := Task("B", Noop).Parallelizable()
+	taskC := Task("C", Noop).Parallelizable()
+	taskAll := Task("All", Noop).DependsOn(taskA.name, taskB.name, taskC.name)
+	argumentsMap = map[string]string{"target": taskAll.name, "parallel": "3"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal(8, calls)
+	assert.Equal(1, maxRunning)
+}
+
+func TestParallelInvalidWorkers(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	task := Task("Test1", Noop)
+	argumentsMap = map[string]string{"target": task.name, "parallel": "abc"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+}
+
+func TestDryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	called := false
+	Setup(func() error { called = true; return nil })
+	Teardown(func() error { called = true; return nil })
+	TaskSetup(func() error { called = true; return nil })
+	TaskTeardown(func() error { called = true; return nil })
+	Task("Lint", func() error { called = true; return nil })
+	Task("Build", func() error { called = true; return nil }).DependsOn("Lint")
+	Task("Prepare", func() error { called = true; return nil }).DependeeOf("Deploy")
+	Task("Notify", func() error { called = true; return nil })
+	taskDeploy := Task("Deploy", func() error { called = true; return nil }).DependsOn("Build").Then("Notify")
+	argumentsMap = map[string]string{"target": taskDeploy.name, "dry-run": ""}
+
+	// Execute
+	exitCode := Execute()
+	plan, err := resolveExecutionPlan(taskDeploy.name)
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.False(called)
+	assert.Equal(0, len(taskRun))
+	assert.NoError(err)
+	names := []string{}
+	reasons := []string{}
+	for _, entry := range plan {
+		names = append(names, entry.task.name)
+		reasons = append(reasons, entry.reason)
+	}
+	assert.Equal([]string{"Lint", "Build", "Prepare", "Deploy", "Notify"}, names)
+	assert.Equal([]string{"dependency of Build", "dependency of Deploy", "dependee of Deploy", "target", "followup of Deploy"}, reasons)
+}
+
+func TestDryRunExclusive(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Build", Noop)
+	taskDeploy := Task("Deploy", Noop).DependsOn("Build")
+	argumentsMap = map[string]string{"target": taskDeploy.name, "dry-run": "", "exclusive": ""}
+
+	// Execute
+	exitCode := Execute()
+	plan, err := resolveExecutionPlan(taskDeploy.name)
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.NoError(err)
+	assert.Equal(1, len(plan))
+	assert.Equal(taskDeploy, plan[0].task)
+}
+
+func TestExportGraphDot(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Build", Noop)
+	Task("Prepare", Noop).DependeeOf("Deploy")
+	Task("Notify", Noop)
+	Task("Deploy", Noop).DependsOn("Build").Then("Notify")
+
+	// Execute
+	var sb strings.Builder
+	err := ExportGraph(&sb, GraphFormatDot)
+
+	// Validate
+	assert.NoError(err)
+	assert.Contains(sb.String(), `"Build" -> "Deploy" [style=solid, label="dependency"];`)
+	assert.Contains(sb.String(), `"Prepare" -> "Deploy" [style=dashed, label="dependee"];`)
+	assert.Contains(sb.String(), `"Deploy" -> "Notify" [style=dotted, label="followup"];`)
+}
+
+func TestExportGraphMermaid(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Maintenance:Build", Noop)
+	Task("Prepare", Noop).DependeeOf("Deploy")
+	Task("Notify", Noop)
+	Task("Deploy", Noop).DependsOn("Maintenance:Build").Then("Notify")
+	// Simulate the conversion of the dependees when running
+	taskMap["Deploy"].DependsOn("Prepare")
+
+	// Execute
+	var sb strings.Builder
+	err := ExportGraph(&sb, GraphFormatMermaid)
+
+	// Validate
+	assert.NoError(err)
+	assert.Contains(sb.String(), `task0["Maintenance:Build"]`)
+	assert.Contains(sb.String(), "task0 -->|dependency| task3")
+	assert.Contains(sb.String(), "task1 -.->|dependee| task3")
+	assert.Contains(sb.String(), "task3 ==>|followup| task2")
+	assert.NotContains(sb.String(), "task1 -->|dependency| task3")
+}
+
+func TestExportGraphUnknownFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Build", Noop)
+
+	// Execute
+	err := ExportGraph(io.Discard, "svg")
+
+	// Validate
+	assert.Error(err)
+}
+
+func TestDependencyCycle(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	taskCalled := false
+	Task("A", func() error { taskCalled = true; return nil }).DependsOn("B").DependeeOf("C")
+	Task("B", func() error { taskCalled = true; return nil }).DependsOn("C")
+	Task("C", func() error { taskCalled = true; return nil })
+	argumentsMap = map[string]string{"target": "A"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.False(taskCalled)
+	assert.Equal([]string{"A", "B", "C", "A"}, findDependencyCycle())
+}
+
+func TestNoDependencyCycle(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("A", Noop).DependsOn("B", "C")
+	Task("B", Noop).DependsOn("C")
+	Task("C", Noop)
+
+	// Validate
+	assert.Nil(findDependencyCycle())
+}
+
+func TestCriteria(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	publishCalled := false
+	notifyCalled := false
+	deployCalled := false
+	taskSetupCalled := false
+	TaskSetup(func() error { taskSetupCalled = true; return nil })
+	Task("Notify", func() error { notifyCalled = true; return nil })
+	taskPublish := Task("Publish", func() error { publishCalled = true; return nil }).
+		WithCriteria(func() bool { return true }).
+		WithCriteriaMsg(func() bool { return false }, "only on main branch").
+		Then("Notify")
+	taskDeploy := Task("Deploy", func() error { deployCalled = true; return nil }).DependsOn(taskPublish.name)
+	argumentsMap = map[string]string{"target": taskDeploy.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal(2, len(taskRun))
+	assert.False(publishCalled)
+	assert.False(notifyCalled)
+	assert.True(deployCalled)
+	assert.True(taskSetupCalled)
+	assert.True(taskPublish.skipped)
+	assert.Equal("only on main branch", taskPublish.skipReason)
+	assert.False(taskDeploy.skipped)
+}
+
+func TestTaskTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	task := TaskWithContext("Test1", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}).Timeout(50 * time.Millisecond)
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.ErrorIs(task.err, context.DeadlineExceeded)
+	assert.Less(task.duration, 5*time.Second)
+}
+
+func TestGlobalTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	task1 := TaskWithContext("Test1", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	})
+	task2 := Task("Test2", func() error { time.Sleep(100 * time.Millisecond); return nil }).Timeout(time.Minute)
+	taskAll := Task("All", Noop).DependsOn(task1.name, task2.name).DeferOnError()
+	argumentsMap = map[string]string{"target": taskAll.name, "timeout": "50ms"}
+
+	// Execute
+	start := time.Now()
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Less(time.Since(start), 5*time.Second)
+	assert.Equal(3, len(taskRun))
+	assert.ErrorIs(task1.err, context.DeadlineExceeded)
+	assert.Nil(task2.err)
+	assert.ErrorIs(taskAll.deferredErr, context.DeadlineExceeded)
+}
+
+func TestTaskTimeoutWaitsForTask(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	var mutex sync.Mutex
+	running := 0
+	maxRunning := 0
+	slowEnd := time.Time{}
+	nextStart := time.Time{}
+	slow := Task("Slow", func() error {
+		mutex.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mutex.Unlock()
+		err := MeasureTime("Sleep", func() error {
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		})
+		mutex.Lock()
+		running--
+		slowEnd = time.Now()
+		mutex.Unlock()
+		return err
+	}).Timeout(50*time.Millisecond).Retry(3, 0)
+	next := Task("Next", func() error {
+		mutex.Lock()
+		nextStart = time.Now()
+		mutex.Unlock()
+		return MeasureTime("Next", func() error { return nil })
+	})
+	taskAll := Task("All", Noop).DependsOn(slow.name, next.name).DeferOnError()
+	argumentsMap = map[string]string{"target": taskAll.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.ErrorIs(slow.err, context.DeadlineExceeded)
+	assert.Equal(3, slow.attempts)
+	assert.Equal(1, maxRunning)
+	assert.Equal(3, len(slow.timeMeasurements))
+	assert.Equal(1, len(next.timeMeasurements))
+	assert.True(nextStart.After(slowEnd))
+}
+
+func TestInvalidTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	task := Task("Test1", Noop)
+	argumentsMap = map[string]string{"target": task.name, "timeout": "soon"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	calls := 0
+	task := Task("Test1", func() error {
+		calls++
+		return MeasureTime("Push", func() error {
+			if calls < 3 {
+				return fmt.Errorf("network error")
+			}
+			return nil
+		})
+	}).Retry(3, 10*time.Millisecond)
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal(3, calls)
+	assert.Equal(3, task.attempts)
+	assert.Nil(task.err)
+	assert.Equal(3, len(task.timeMeasurements))
+	assert.Equal(1, task.timeMeasurements[0].attempt)
+	assert.Equal(3, task.timeMeasurements[2].attempt)
+}
+
+func TestRetryFails(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	desiredExitCode := 10
+	calls := 0
+	task := Task("Test1", func() error { calls++; return getExitError(desiredExitCode) }).Retry(2, 0)
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(desiredExitCode, exitCode)
+	assert.Equal(2, calls)
+	assert.Equal(2, task.attempts)
+	assertExitError(assert, task.err, desiredExitCode)
+}
+
+func TestRetryStopsOnInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending signals is not supported on windows")
+	}
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	calls := 0
+	task := TaskWithContext("Test1", func(ctx context.Context) error {
+		calls++
+		process, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return err
+		}
+		if err := process.Signal(os.Interrupt); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}).Retry(5, 0)
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(130, exitCode)
+	assert.Equal(1, calls)
+	assert.Equal(1, task.attempts)
+	assert.ErrorIs(task.err, context.Canceled)
+}
+
+func TestInterruptSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending signals is not supported on windows")
+	}
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	teardownCalled := false
+	taskTeardownCalled := false
+	nextCalled := false
+	Teardown(func() error { teardownCalled = true; return nil })
+	TaskTeardown(func() error { taskTeardownCalled = true; return nil })
+	task1 := TaskWithContext("Test1", func(ctx context.Context) error {
+		process, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return err
+		}
+		if err := process.Signal(os.Interrupt); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	task2 := Task("Test2", func() error { nextCalled = true; return nil })
+	taskAll := Task("All", Noop).DependsOn(task1.name, task2.name).DeferOnError()
+	argumentsMap = map[string]string{"target": taskAll.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(130, exitCode)
+	assert.True(teardownCalled)
+	assert.True(taskTeardownCalled)
+	assert.False(nextCalled)
+	assert.Equal(1, len(taskRun))
+	assert.ErrorIs(task1.err, context.Canceled)
+}
+
+func TestInterruptSignalStopsTaskBeforeTeardown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending signals is not supported on windows")
+	}
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	var mutex sync.Mutex
+	taskEnd := time.Time{}
+	teardownStart := time.Time{}
+	var processErr error
+	Teardown(func() error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		teardownStart = time.Now()
+		return nil
+	})
+	task := Task("Test1", func() error {
+		defer func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			taskEnd = time.Now()
+		}()
+		process, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return err
+		}
+		if err := process.Signal(os.Interrupt); err != nil {
+			return err
+		}
+		// Start a process from another goroutine with the context the tools use
+		done := make(chan error)
+		go func() {
+			done <- exec.CommandContext(toolctx.Get(), "sleep", "5").Run()
+		}()
+		processErr = <-done
+		// Ignore the context and keep running for a while
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	start := time.Now()
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(130, exitCode)
+	assert.Less(time.Since(start), 5*time.Second)
+	assert.Error(processErr)
+	assert.ErrorIs(task.err, context.Canceled)
+	assert.True(taskEnd.Before(teardownStart))
+}
+
+func TestInterruptSignalToolsInTeardown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending signals is not supported on windows")
+	}
+	if _, err := exec.LookPath("npm"); err != nil {
+		t.Skip("npm is not available")
+	}
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	workDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(workDir, "package.json"), []byte(`{"scripts": {"cleanup": "echo cleaned >> cleaned.txt"}}`), 0644)
+	assert.NoError(err)
+	cleanup := func() error {
+		return Tools.Npm.Run(&gttools.NpmRunSettings{
+			ToolSettingsBase: gttools.ToolSettingsBase{WorkingDirectory: workDir},
+			Script:           "cleanup",
+		})
+	}
+	var taskTeardownErr, teardownErr error
+	TaskTeardown(func() error {
+		taskTeardownErr = cleanup()
+		return taskTeardownErr
+	})
+	Teardown(func() error {
+		teardownErr = cleanup()
+		return teardownErr
+	})
+	task := TaskWithContext("Test1", func(ctx context.Context) error {
+		process, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return err
+		}
+		if err := process.Signal(os.Interrupt); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(130, exitCode)
+	assert.NoError(taskTeardownErr)
+	assert.NoError(teardownErr)
+	content, err := os.ReadFile(filepath.Join(workDir, "cleaned.txt"))
+	assert.NoError(err)
+	assert.Equal("cleaned\ncleaned\n", string(content))
+}
+
+func TestJsonReport(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	desiredExitCode := 10
+	reportPath := filepath.Join(t.TempDir(), "reports", "report.json")
+	task1 := Task("Test1", func() error {
+		return MeasureTime("Sub-Item", func() error { return nil })
+	})
+	task2 := Task("Test2", func() error { return fmt.Errorf("ignored") }).ContinueOnError()
+	task3 := Task("Test3", Noop).WithCriteriaMsg(func() bool { return false }, "not needed")
+	task4 := Task("Test4", func() error { return getExitError(desiredExitCode) })
+	taskAll := Task("All", Noop).DependsOn(task1.name, task2.name, task3.name, task4.name).DeferOnError()
+	argumentsMap = map[string]string{"target": taskAll.name, "report-json": reportPath}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(desiredExitCode, exitCode)
+	data, err := os.ReadFile(reportPath)
+	assert.NoError(err)
+	report := &jsonReport{}
+	assert.NoError(json.Unmarshal(data, report))
+	assert.Equal(desiredExitCode, report.ExitCode)
+	assert.Equal(5, len(report.Tasks))
+	assert.Equal("Test1", report.Tasks[0].Name)
+	assert.Equal(taskStatusSuccess, report.Tasks[0].Status)
+	assert.False(report.Tasks[0].StartTime.IsZero())
+	assert.Equal(1, len(report.Tasks[0].TimeMeasurements))
+	assert.Equal("Sub-Item", report.Tasks[0].TimeMeasurements[0].Name)
+	assert.Equal(taskStatusSuccess, report.Tasks[1].Status)
+	assert.Equal("ignored", report.Tasks[1].IgnoredError)
+	assert.Equal(taskStatusSkipped, report.Tasks[2].Status)
+	assert.Equal("not needed", report.Tasks[2].SkipReason)
+	assert.Equal(taskStatusFailed, report.Tasks[3].Status)
+	assert.Equal(desiredExitCode, report.Tasks[3].ExitCode)
+	assert.Equal("exit status 10", report.Tasks[3].Error)
+	assert.Equal(taskStatusFailed, report.Tasks[4].Status)
+	assert.Equal("exit status 10", report.Tasks[4].DeferredError)
+}
+
+func TestJUnitReport(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	desiredExitCode := 10
+	reportPath := filepath.Join(t.TempDir(), "junit.xml")
+	task1 := Task("Test1", func() error { return fmt.Errorf("ignored") }).ContinueOnError()
+	task2 := Task("Test2", Noop).WithCriteriaMsg(func() bool { return false }, "not needed")
+	task3 := Task("Test3", func() error { return getExitError(desiredExitCode) })
+	taskAll := Task("All", Noop).DependsOn(task1.name, task2.name, task3.name).DeferOnError()
+	argumentsMap = map[string]string{"target": taskAll.name, "report-junit": reportPath}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(desiredExitCode, exitCode)
+	data, err := os.ReadFile(reportPath)
+	assert.NoError(err)
+	report := &junitTestSuites{}
+	assert.NoError(xml.Unmarshal(data, report))
+	assert.Equal(4, report.Tests)
+	assert.Equal(2, report.Failures)
+	assert.Equal(1, report.Skipped)
+	assert.Equal(1, len(report.TestSuites))
+	testCases := report.TestSuites[0].TestCases
+	assert.Equal(4, len(testCases))
+	assert.Equal("Test1", testCases[0].Name)
+	assert.Nil(testCases[0].Failure)
+	assert.Equal("Ignored error: ignored", testCases[0].SystemOut)
+	assert.NotNil(testCases[1].Skipped)
+	assert.Equal("not needed", testCases[1].Skipped.Message)
+	assert.NotNil(testCases[2].Failure)
+	assert.Equal("Task error: exit status 10", testCases[2].Failure.Message)
+	assert.NotNil(testCases[3].Failure)
+	assert.Equal("Deferred error: exit status 10", testCases[3].Failure.Message)
+}
+
+func TestReportsMaskSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	reportDir := t.TempDir()
+	stateDir := filepath.Join(reportDir, ".gotaskr")
+	loginErr := func() error {
+		token, _ := GetArgument("token")
+		return fmt.Errorf("login with %s failed", token)
+	}
+	task1 := Task("Test1", loginErr).ContinueOnError()
+	task2 := Task("Test2", Noop).WithCriteriaMsg(func() bool { return false }, "not needed for abc123")
+	task3 := Task("Test3", loginErr)
+	taskAll := Task("All", Noop).DependsOn(task1.name, task2.name, task3.name).DeferOnError().
+		Argument("token", "The access token", false).Secret()
+	argumentsMap = map[string]string{
+		"target":       taskAll.name,
+		"token":        "abc123",
+		"report-json":  filepath.Join(reportDir, "report.json"),
+		"report-junit": filepath.Join(reportDir, "junit.xml"),
+		"state-dir":    stateDir,
+	}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	for _, reportPath := range []string{"report.json", "junit.xml", filepath.Join(".gotaskr", "last-run.json")} {
+		data, err := os.ReadFile(filepath.Join(reportDir, reportPath))
+		assert.NoError(err)
+		assert.NotContains(string(data), "abc123", reportPath)
+		assert.Contains(string(data), "login with *** failed", reportPath)
+	}
+}
+
+func TestInputsAndOutputs(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "src", "sub", "input.txt")
+	outputPath := filepath.Join(tempDir, "dist", "output.txt")
+	assert.NoError(os.MkdirAll(filepath.Dir(inputPath), os.ModePerm))
+	assert.NoError(os.WriteFile(inputPath, []byte("v1"), os.ModePerm))
+	calls := 0
+	run := func() int {
+		clear()
+		task := Task("Build:App", func() error {
+			calls++
+			if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+				return err
+			}
+			return os.WriteFile(outputPath, []byte("output"), os.ModePerm)
+		}).Inputs(filepath.Join(tempDir, "src", "**", "*.txt")).Outputs(filepath.Join(tempDir, "dist"))
+		argumentsMap = map[string]string{"target": task.name, "state-dir": filepath.Join(tempDir, ".gotaskr")}
+		return Execute()
+	}
+
+	// Execute and validate
+	assert.Equal(0, run())
+	assert.Equal(1, calls)
+	assert.False(taskRun[0].upToDate)
+
+	assert.Equal(0, run())
+	assert.Equal(1, calls)
+	assert.True(taskRun[0].upToDate)
+
+	assert.NoError(os.WriteFile(inputPath, []byte("v2"), os.ModePerm))
+	assert.Equal(0, run())
+	assert.Equal(2, calls)
+
+	assert.NoError(os.Remove(outputPath))
+	assert.Equal(0, run())
+	assert.Equal(3, calls)
+
+	assert.Equal(0, run())
+	assert.Equal(3, calls)
+	assert.True(taskRun[0].upToDate)
+}
+
+func TestInputsWithStateDirectory(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	t.Chdir(t.TempDir())
+	assert.NoError(os.WriteFile("input.txt", []byte("v1"), os.ModePerm))
+	calls := 0
+	run := func() int {
+		clear()
+		task := Task("Build", func() error {
+			calls++
+			return nil
+		}).Inputs("**")
+		argumentsMap = map[string]string{"target": task.name, "state-dir": ".gotaskr"}
+		return Execute()
+	}
+
+	// Execute and validate
+	assert.Equal(0, run())
+	assert.Equal(1, calls)
+	assert.DirExists(".gotaskr")
+
+	assert.Equal(0, run())
+	assert.Equal(1, calls)
+	assert.True(taskRun[0].upToDate)
+}
+
+func TestGlobFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	tempDir := t.TempDir()
+	for _, file := range []string{"a.go", "b.txt", "sub/c.go", "sub/deep/d.go", "other/e.go"} {
+		filePath := filepath.Join(tempDir, file)
+		assert.NoError(os.MkdirAll(filepath.Dir(filePath), os.ModePerm))
+		assert.NoError(os.WriteFile(filePath, []byte(file), os.ModePerm))
+	}
+
+	// Execute
+	allGoFiles, err1 := globFiles([]string{filepath.Join(tempDir, "**", "*.go")})
+	subFiles, err2 := globFiles([]string{filepath.Join(tempDir, "sub"), filepath.Join(tempDir, "sub", "*.go")})
+	txtFiles, err3 := globFiles([]string{filepath.Join(tempDir, "*.txt")})
+
+	// Validate
+	assert.NoError(err1)
+	assert.NoError(err2)
+	assert.NoError(err3)
+	assert.Equal(4, len(allGoFiles))
+	assert.Equal([]string{filepath.Join(tempDir, "sub", "c.go"), filepath.Join(tempDir, "sub", "deep", "d.go")}, subFiles)
+	assert.Equal([]string{filepath.Join(tempDir, "b.txt")}, txtFiles)
+}
+
+func TestMultipleTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	setupCalls := 0
+	teardownCalls := 0
+	sharedCalls := 0
+	Setup(func() error { setupCalls++; return nil })
+	Teardown(func() error { teardownCalls++; return nil })
+	Task("Shared", func() error { sharedCalls++; return nil })
+	taskA := Task("A", Noop).DependsOn("Shared")
+	taskB := Task("B", Noop).DependsOn("Shared")
+	taskC := Task("C", Noop)
+	argumentsMap = map[string]string{"target": "A, B,C"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal(1, setupCalls)
+	assert.Equal(1, teardownCalls)
+	assert.Equal(1, sharedCalls)
+	assert.Equal([]*TaskObject{taskMap["Shared"], taskA, taskB, taskC}, taskRun)
+}
+
+func TestMultipleTargetsWithError(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	desiredExitCode := 10
+	taskA := Task("A", func() error { return getExitError(desiredExitCode) })
+	taskB := Task("B", Noop)
+	argumentsMap = map[string]string{"target": "A,B"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(desiredExitCode, exitCode)
+	assert.Equal(1, len(taskRun))
+	assert.True(taskA.didRun)
+	assert.False(taskB.didRun)
+}
+
+func TestSkipArgument(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, parallel := range []string{"1", "2"} {
+		// Prepare
+		clear()
+		startDbCalled := false
+		testsCalled := false
+		deployCalled := false
+		Task("Start-Db", func() error { startDbCalled = true; return nil })
+		Task("Build", Noop)
+		taskTests := Task("Integration-Tests", func() error { testsCalled = true; return nil }).DependsOn("Start-Db")
+		taskDeploy := Task("Deploy", func() error { deployCalled = true; return nil }).DependsOn("Build", taskTests.name)
+		argumentsMap = map[string]string{"target": taskDeploy.name, "skip": taskTests.name, "parallel": parallel}
+
+		// Execute
+		exitCode := Execute()
+
+		// Validate
+		assert.Equal(0, exitCode)
+		assert.False(startDbCalled)
+		assert.False(testsCalled)
+		assert.True(deployCalled)
+		assert.Equal(3, len(taskRun))
+		assert.True(taskTests.skipped)
+		assert.Equal("excluded with --skip", taskTests.skipReason)
+	}
+}
+
+func TestSkipArgumentUnknownTask(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	task := Task("Test1", Noop)
+	argumentsMap = map[string]string{"target": task.name, "skip": "Unknown"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+}
+
+func TestRerunFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	stateDir := filepath.Join(t.TempDir(), ".gotaskr")
+	called := []string{}
+	failB := true
+	record := recordCalls(&called)
+	recordB := func() error {
+		if err := record("B")(); err != nil || !failB {
+			return err
+		}
+		return fmt.Errorf("B failed")
+	}
+	Task("A", record("A"))
+	Task("B", recordB)
+	Task("C", record("C")).DependsOn("B")
+	Task("All", record("All")).DependsOn("A", "C")
+	argumentsMap = map[string]string{"target": "All", "state-dir": stateDir}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal([]string{"A", "B"}, called)
+
+	// Rerun the failed tasks without giving a target
+	clear()
+	called = []string{}
+	failB = false
+	Task("A", record("A"))
+	Task("B", recordB)
+	Task("C", record("C")).DependsOn("B")
+	Task("All", record("All")).DependsOn("A", "C")
+	argumentsMap = map[string]string{"rerun-failed": "", "state-dir": stateDir}
+
+	// Execute
+	exitCode = Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal([]string{"B", "C", "All"}, called)
+	assert.True(taskMap["A"].skipped)
+	assert.Equal("succeeded in the previous run", taskMap["A"].skipReason)
+}
+
+func TestRerunFailedWithoutPreviousRun(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	task := Task("Test1", Noop)
+	argumentsMap = map[string]string{"target": task.name, "rerun-failed": "", "state-dir": filepath.Join(t.TempDir(), ".gotaskr")}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+}
+
+func TestTags(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	called := []string{}
+	record := recordCalls(&called)
+	Task("Lint1", record("Lint1")).Tags("lint")
+	Task("Build", record("Build")).Tags("ci")
+	Task("Lint2", record("Lint2")).Tags("lint", "ci").DependsOn("Build")
+	Task("Other", record("Other"))
+	argumentsMap = map[string]string{"tags": "lint"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal([]string{"Lint1", "Build", "Lint2"}, called)
+}
+
+func TestTagsWithTarget(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	called := []string{}
+	record := recordCalls(&called)
+	Task("Lint", record("Lint")).Tags("lint")
+	Task("Build", record("Build")).Tags("lint")
+	argumentsMap = map[string]string{"target": "Build", "tags": "lint"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal([]string{"Build", "Lint"}, called)
+}
+
+func TestTagsUnknown(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Test1", Noop).Tags("ci")
+	argumentsMap = map[string]string{"tags": "unknown"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+}
+
+func TestTaskListTree(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Build", Noop).Description("Builds everything")
+	Task("Maintenance:Update-Dependencies", Noop)
+	Task("Test", Noop)
+	Task("Maintenance:Docs:Generate", Noop).Description("Generates the docs")
+
+	// Execute
+	list := formatTaskList()
+
+	// Validate
+	expected := []string{
+		"- Build",
+		"  Builds everything",
+		"- Maintenance:",
+		"  - Update-Dependencies",
+		"  - Docs:",
+		"    - Generate",
+		"      Generates the docs",
+		"- Test",
+		"",
+	}
+	assert.Equal(strings.Join(expected, log.Newline), list)
+}
+
+func TestTargetWildcard(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	called := []string{}
+	record := recordCalls(&called)
+	Task("Build", record("Build"))
+	Task("Maintenance:Update", record("Maintenance:Update"))
+	Task("Maintenance:Docs:Generate", record("Maintenance:Docs:Generate"))
+	Task("Maintenance", record("Maintenance"))
+	argumentsMap = map[string]string{"target": "Maintenance:*"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal([]string{"Maintenance:Update", "Maintenance:Docs:Generate"}, called)
+}
+
+func TestTargetWildcardWithoutMatch(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Build", Noop)
+	argumentsMap = map[string]string{"target": "Maintenance:*"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+}
+
+func TestListArgument(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	called := false
+	Task("Default", func() error {
+		called = true
+		return nil
+	})
+	argumentsMap = map[string]string{"list": ""}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.False(called)
+}
+
+func TestTypedArguments(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	var retries int
+	var force bool
+	var wait time.Duration
+	var mode string
+	task := Task("Test1", func() error {
+		var err error
+		if retries, err = GetArgumentInt("retries"); err != nil {
+			return err
+		}
+		if force, err = GetArgumentBool("force"); err != nil {
+			return err
+		}
+		if wait, err = GetArgumentDuration("wait"); err != nil {
+			return err
+		}
+		if mode, err = GetArgumentEnum("mode"); err != nil {
+			return err
+		}
+		return nil
+	}).
+		ArgumentInt("retries", "The number of retries", 3).
+		ArgumentBool("force", "Force the run", false).
+		ArgumentDuration("wait", "The time to wait", time.Second).
+		ArgumentEnum("mode", "The build mode", "release", "debug", "release")
+	argumentsMap = map[string]string{"target": task.name, "force": "", "mode": "debug"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal(3, retries)
+	assert.True(force)
+	assert.Equal(time.Second, wait)
+	assert.Equal("debug", mode)
+}
+
+func TestArgumentEnumDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	var mode string
+	var err error
+	task := Task("Test1", func() error {
+		mode, err = GetArgumentEnum("mode")
+		return err
+	}).ArgumentEnum("mode", "The build mode", "release", "debug", "release")
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.NoError(err)
+	assert.Equal("release", mode)
+
+	// Prepare a default value which is not allowed
+	clear()
+	task = Task("Test1", Noop).ArgumentEnum("mode", "The build mode", "profile", "debug", "release")
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	exitCode = Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+	assert.EqualError(task.arguments[0].defaultErr, "expected one of debug, release")
+}
+
+func TestTypedArgumentsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"retries": "abc",
+		"force":   "maybe",
+		"wait":    "5",
+		"mode":    "profile",
+	}
+	for argName, value := range tests {
+		t.Run(argName, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare
+			clear()
+			task := Task("Test1", Noop).
+				ArgumentInt("retries", "The number of retries", 3).
+				ArgumentBool("force", "Force the run", false).
+				ArgumentDuration("wait", "The time to wait", time.Second).
+				ArgumentEnum("mode", "The build mode", "release", "debug", "release")
+			argumentsMap = map[string]string{"target": task.name, argName: value}
+
+			// Execute
+			exitCode := Execute()
+
+			// Validate
+			assert.Equal(1, exitCode)
+			assert.Equal(0, len(taskRun))
+		})
+	}
+}
+
+func TestRequiredArguments(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Dependency", Noop).Argument("token", "The access token", false)
+	task := Task("Test1", Noop).
+		DependsOn("Dependency").
+		Argument("version", "The version to build", false).
+		Argument("comment", "An optional comment", true)
+	Task("Other", Noop).Argument("other", "Not part of the run", false)
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	errs := findMissingArguments([]*TaskObject{taskMap["Dependency"], task})
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+	assert.Equal(2, len(errs))
+	assert.EqualError(errs[0], "missing required argument 'token' of task 'Dependency': The access token")
+	assert.EqualError(errs[1], "missing required argument 'version' of task 'Test1': The version to build")
+
+	// Prepare
+	clear()
+	Task("Dependency", Noop).Argument("token", "The access token", false)
+	task = Task("Test1", Noop).
+		DependsOn("Dependency").
+		Argument("version", "The version to build", false)
+	Task("Other", Noop).Argument("other", "Not part of the run", false)
+	argumentsMap = map[string]string{"target": task.name, "token": "abc", "version": "1.0.0"}
+
+	// Execute
+	exitCode = Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal(2, len(taskRun))
+}
+
+func TestRequiredArgumentsOfExcludedTasks(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Integration-Tests", Noop).Argument("db-password", "The password of the database", false)
+	Task("Deploy", Noop).DependsOn("Integration-Tests")
+	argumentsMap = map[string]string{"target": "Deploy", "skip": "Integration-Tests"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal(2, len(taskRun))
+	assert.True(taskMap["Integration-Tests"].skipped)
+
+	// Prepare a run which fails after the task with the argument succeeded
+	clear()
+	stateDir := filepath.Join(t.TempDir(), ".gotaskr")
+	Task("Integration-Tests", Noop).Argument("db-password", "The password of the database", false)
+	Task("Deploy", func() error { return fmt.Errorf("deploy failed") }).DependsOn("Integration-Tests")
+	argumentsMap = map[string]string{"target": "Deploy", "db-password": "secret", "state-dir": stateDir}
+
+	// Execute
+	exitCode = Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+
+	// Rerun the failed tasks without the argument
+	clear()
+	Task("Integration-Tests", Noop).Argument("db-password", "The password of the database", false)
+	Task("Deploy", Noop).DependsOn("Integration-Tests")
+	argumentsMap = map[string]string{"rerun-failed": "", "state-dir": stateDir}
+
+	// Execute
+	exitCode = Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.True(taskMap["Integration-Tests"].skipped)
+}
+
+func TestArgumentFromEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	t.Setenv("TEST_REGISTRY_PASSWORD", "secret")
+	t.Setenv("GOTASKR_ARG_BUILD_MODE", "debug")
+	t.Setenv("GOTASKR_ARG_VERSION", "from-env")
+	var password, mode, version string
+	task := Task("Test1", func() error {
+		password, _ = GetArgument("registry-password")
+		mode, _ = GetArgument("build-mode")
+		version, _ = GetArgument("version")
+		return nil
+	}).
+		Argument("registry-password", "The password of the registry", false).FromEnv("TEST_REGISTRY_PASSWORD").
+		Argument("build-mode", "The build mode", false)
+	argumentsMap = map[string]string{"target": task.name, "version": "from-cli"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal("secret", password)
+	assert.Equal("debug", mode)
+	assert.Equal("from-cli", version)
+	assert.Equal("GOTASKR_ARG_BUILD_MODE", getConventionalEnvironmentVariable("build-mode"))
+}
+
+func TestSecretArguments(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	task := Task("Test1", func() error {
+		token, _ := GetArgument("token")
+		log.Informationf("Using token %s and %s", token, "registered-secret")
+		return nil
+	}).Argument("token", "The access token", false).Secret()
+	RegisterSecret("registered-secret")
+	argumentsMap = map[string]string{"target": task.name, "token": "abc123", "verbose": ""}
+
+	// Execute
+	var exitCode int
+	output := captureStdout(t, func() {
+		exitCode = Execute()
+	})
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Contains(output, "token=\"***\"")
+	assert.Contains(output, "Using token *** and ***")
+	assert.NotContains(output, "abc123")
+	assert.NotContains(output, "registered-secret")
+}
+
+func TestConfigFile(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configPath, []byte("registry: registry.example.com\nversion: 1.0.0\nretries: 3\nplatforms:\n  - linux\n  - windows\n"), os.ModePerm)
+	assert.NoError(err)
+	values := map[string]string{}
+	task := Task("Test1", func() error {
+		for _, name := range []string{"registry", "version", "retries", "platforms"} {
+			values[name], _ = GetArgument(name)
+		}
+		return nil
+	}).Argument("registry", "The registry", false)
+	argumentsMap = map[string]string{"target": task.name, "config": configPath, "version": "2.0.0"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal("registry.example.com", values["registry"])
+	assert.Equal("2.0.0", values["version"])
+	assert.Equal("3", values["retries"])
+	assert.Equal("linux,windows", values["platforms"])
+}
+
+func TestConfigFileDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	t.Chdir(t.TempDir())
+	err := os.WriteFile("gotaskr.json", []byte(`{"registry": "registry.example.com", "verbose": null}`), os.ModePerm)
+	assert.NoError(err)
+	task := Task("Test1", Noop)
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	registry, _ := GetArgument("registry")
+	assert.Equal("registry.example.com", registry)
+	assert.True(HasArgument("verbose"))
+}
+
+func TestConfigFileNumbers(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, configFile := range []struct {
+		name    string
+		content string
+	}{
+		{"config.json", `{"build": 1234567, "version": 1.10, "ratio": 0.5, "sizes": [1000000, 2.50]}`},
+		{"config.yaml", "build: 1234567\nversion: 1.10\nratio: 0.5\nsizes: [1000000, 2.50]\n"},
+	} {
+		t.Run(configFile.name, func(t *testing.T) {
+			// Prepare
+			clear()
+			configPath := filepath.Join(t.TempDir(), configFile.name)
+			err := os.WriteFile(configPath, []byte(configFile.content), os.ModePerm)
+			assert.NoError(err)
+			task := Task("Test1", Noop)
+			argumentsMap = map[string]string{"target": task.name, "config": configPath}
+
+			// Execute
+			exitCode := Execute()
+
+			// Validate
+			assert.Equal(0, exitCode)
+			build, _ := GetArgument("build")
+			version, _ := GetArgument("version")
+			ratio, _ := GetArgument("ratio")
+			sizes, _ := GetArgument("sizes")
+			assert.Equal("1234567", build)
+			assert.Equal("1.10", version)
+			assert.Equal("0.5", ratio)
+			assert.Equal("1000000,2.50", sizes)
+		})
+	}
+}
+
+func TestConfigFileMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	task := Task("Test1", Noop)
+	argumentsMap = map[string]string{"target": task.name, "config": filepath.Join(t.TempDir(), "missing.yaml")}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+}
+
+func TestEnvFile(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	for _, key := range []string{"TEST_REGISTRY_PASSWORD", "GOTASKR_ARG_REGISTRY", "TEST_EXISTING"} {
+		// Make sure the variables are restored after the test
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("TEST_EXISTING", "from-environment")
+	envPath := filepath.Join(t.TempDir(), "test.env")
+	content := "# Comment\n\nexport TEST_REGISTRY_PASSWORD=\"secret\"\nGOTASKR_ARG_REGISTRY = registry.example.com\nTEST_EXISTING=from-file\n"
+	err := os.WriteFile(envPath, []byte(content), os.ModePerm)
+	assert.NoError(err)
+	var password, registry string
+	task := Task("Test1", func() error {
+		password, _ = GetArgument("password")
+		registry, _ = GetArgument("registry")
+		return nil
+	}).Argument("password", "The password", false).FromEnv("TEST_REGISTRY_PASSWORD")
+	argumentsMap = map[string]string{"target": task.name, "env-file": envPath}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal("secret", password)
+	assert.Equal("registry.example.com", registry)
+	assert.Equal("from-environment", os.Getenv("TEST_EXISTING"))
+}
+
+func TestEnvFileInvalidLine(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	t.Chdir(t.TempDir())
+	t.Setenv("TEST_VALID", "")
+	os.Unsetenv("TEST_VALID")
+	err := os.WriteFile(".env", []byte("TEST_VALID=1\nPASSTHROUGH_VAR\n"), os.ModePerm)
+	assert.NoError(err)
+	task := Task("Test1", Noop)
+	argumentsMap = map[string]string{"target": task.name}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal("1", os.Getenv("TEST_VALID"))
+
+	// Prepare an explicitly given env file
+	clear()
+	task = Task("Test1", Noop)
+	argumentsMap = map[string]string{"target": task.name, "env-file": ".env"}
+
+	// Execute
+	exitCode = Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+	assert.Equal(0, len(taskRun))
+}
+
+func TestGetArgumentList(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	parsed := argparse.ParseArgStringResult(strings.Fields("--tag a --tag=b,c --single x,y"))
+	argumentsMap = parsed.Map()
+	argumentOccurrences = parsed.Occurrences()
+	configArguments = map[string]string{"platform": "linux, windows"}
+
+	// Validate
+	assert.Equal([]string{"a", "b", "c"}, GetArgumentList("tag"))
+	assert.Equal([]string{"x", "y"}, GetArgumentList("single"))
+	assert.Equal([]string{"linux", "windows"}, GetArgumentList("platform"))
+	assert.Equal([]string{}, GetArgumentList("missing"))
+}
+
+func TestRepeatedTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	called := []string{}
+	record := recordCalls(&called)
+	Task("A", record("A"))
+	Task("B", record("B"))
+	Task("C", record("C"))
+	parsed := argparse.ParseArgStringResult(strings.Fields("--target C --target A,B"))
+	argumentsMap = parsed.Map()
+	argumentOccurrences = parsed.Occurrences()
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal([]string{"C", "A", "B"}, called)
+}
+
+func TestPassthroughArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	var customArguments []string
+	task := Task("Npm:Run", func() error {
+		customArguments = append([]string{"--silent"}, GetPassthroughArgs()...)
+		return nil
+	})
+	parsed := argparse.ParseArgStringResult([]string{"--target", task.name, "--", "--watch", "--name", "a b"})
+	argumentsMap = parsed.Map()
+	argumentOccurrences = parsed.Occurrences()
+	passthroughArgs = parsed.Passthrough()
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal([]string{"--silent", "--watch", "--name", "a b"}, customArguments)
+	assert.False(HasArgument("watch"))
+}
+
+func TestGetArgumentBool(t *testing.T) {
+	tests := []struct {
+		args     string
+		expected bool
+		invalid  bool
+	}{
+		{"--flag", true, false},
+		{"--flag=true", true, false},
+		{"--flag=YES", true, false},
+		{"--flag=1", true, false},
+		{"--flag=false", false, false},
+		{"--flag=No", false, false},
+		{"--flag=0", false, false},
+		{"--no-flag", false, false},
+		{"--flag --no-flag", false, false},
+		{"--no-flag --flag", true, false},
+		{"--other", true, false},
+		{"--flag=maybe", false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.args, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare
+			clear()
+			Task("Test1", Noop).ArgumentBool("flag", "A flag which is on by default", true)
+			parsed := argparse.ParseArgStringResult(strings.Fields(test.args))
+			argumentsMap = parsed.Map()
+			argumentOccurrences = parsed.Occurrences()
+
+			// Execute
+			value, err := GetArgumentBool("flag")
+
+			// Validate
+			if test.invalid {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expected, value)
+		})
+	}
+}
+
+func TestExclusiveTurnedOff(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	dependencyCalled := false
+	Task("Dependency", func() error {
+		dependencyCalled = true
+		return nil
+	})
+	task := Task("Test1", Noop).DependsOn("Dependency")
+	argumentsMap = map[string]string{"target": task.name, "exclusive": "false"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.True(dependencyCalled)
+}
+
+func TestCompletionScript(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Prepare
+			clear()
+			var sb strings.Builder
+
+			// Execute
+			err := writeCompletionScript(&sb, shell)
+
+			// Validate
+			assert.NoError(err)
+			assert.Contains(sb.String(), "--__complete --")
+			assert.Contains(sb.String(), filepath.Base(os.Args[0]))
+		})
+	}
+}
+
+func TestCompletionScriptUnknownShell(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	argumentsMap = map[string]string{"completion": "powershell"}
+
+	// Execute
+	exitCode := Execute()
+
+	// Validate
+	assert.Equal(1, exitCode)
+}
+
+func TestCompletions(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Build", Noop).Tags("ci").ArgumentEnum("mode", "The build mode", "release", "debug", "release")
+	Task("Maintenance:Update", Noop).Argument("token", "The access token", true)
+	Task("Maintenance:Docs", Noop).ArgumentBool("force", "Force the update", false)
+
+	// Validate
+	assert.Equal([]string{"Build", "Maintenance:Update", "Maintenance:Docs"}, getCompletions("--target", ""))
+	assert.Equal([]string{"Maintenance:Update", "Maintenance:Docs"}, getCompletions("--target", "Main"))
+	assert.Equal([]string{"Build,Maintenance:Docs"}, getCompletions("--target", "Build,Maintenance:D"))
+	assert.Equal([]string{"--target=Build"}, getCompletions("", "--target=B"))
+	assert.Equal([]string{"ci"}, getCompletions("--tags", ""))
+	assert.Equal([]string{"debug"}, getCompletions("--mode", "d"))
+	assert.Equal([]string{"true", "false"}, getCompletions("--force", ""))
+	assert.Equal([]string{"--target", "--tags", "--timeout", "--token"}, getCompletions("Build", "--t"))
+	assert.Equal([]string{}, getCompletions("--token", ""))
+	assert.Equal([]string{}, getCompletions("Build", ""))
+}
+
+func TestCompletionsArgument(t *testing.T) {
+	assert := assert.New(t)
+
+	// Prepare
+	clear()
+	Task("Build", Noop)
+	Task("Test", Noop)
+	parsed := argparse.ParseArgStringResult([]string{"--__complete", "--", "--target", "T"})
+	argumentsMap = parsed.Map()
+	argumentOccurrences = parsed.Occurrences()
+	passthroughArgs = parsed.Passthrough()
+
+	// Execute
+	var exitCode int
+	output := captureStdout(t, func() {
+		exitCode = Execute()
+	})
+
+	// Validate
+	assert.Equal(0, exitCode)
+	assert.Equal("Test\n", output)
+	assert.Equal(0, len(taskRun))
+}
+
+////////////////////
+// Helpers
+////////////////////
+
+func getExitError(errorCode int) error {
+	return goext.CmdRunners.Default.Run(getBashPath(), "-c", fmt.Sprintf("exit %d", errorCode))
+}
+
+func getBashPath() string {
+	if runtime.GOOS == "windows" {
+		return `C:\Program Files\Git\bin\bash.exe`
+	}
+	return "/bin/bash"
+}
+
+func assertExitError(assert *assert.Assertions, err error, exitCode int) {
+	assert.NotNil(err)
+	if err != nil {
+		ierr, isError := err.(*exec.ExitError)
+		assert.True(isError)
+		assert.Equal(exitCode, ierr.ExitCode())
+	}
+}
+
+// recordCalls returns a function to create task functions which append the given name to the called tasks.
+func recordCalls(called *[]string) func(name string) func() error {
+	return func(name string) func() error {
+		return func() error {
+			*called = append(*called, name)
+			return nil
+		}
+	}
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
 	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() {
+		os.Stdout = stdout
+	}()
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+	f()
+	writer.Close()
+	return <-output
 }
@@ -0,0 +1,123 @@
+package gotaskr
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// GraphFormat defines the format of an exported task graph.
+type GraphFormat string
+
+const (
+	GraphFormatDot     GraphFormat = "dot"
+	GraphFormatMermaid GraphFormat = "mermaid"
+)
+
+// graphEdgeKind defines the kind of relation between two tasks.
+type graphEdgeKind string
+
+const (
+	graphEdgeDependency graphEdgeKind = "dependency"
+	graphEdgeDependee   graphEdgeKind = "dependee"
+	graphEdgeFollowup   graphEdgeKind = "followup"
+)
+
+// graphEdge is a relation between two tasks. The edge points in the direction of execution.
+type graphEdge struct {
+	from string
+	to   string
+	kind graphEdgeKind
+}
+
+// ExportGraph writes the graph of all registered tasks in the given format to the writer.
+// The edges point in the order of execution and are styled by their kind (dependency, dependee or followup).
+func ExportGraph(w io.Writer, format GraphFormat) error {
+	edges := getGraphEdges()
+	switch format {
+	case GraphFormatDot:
+		return writeGraphDot(w, edges)
+	case GraphFormatMermaid:
+		return writeGraphMermaid(w, edges)
+	}
+	return fmt.Errorf("unknown graph format: %s", format)
+}
+
+// getGraphEdges gets all edges between the registered tasks in registration order.
+func getGraphEdges() []graphEdge {
+	edges := []graphEdge{}
+	for _, taskName := range taskList {
+		task := taskMap[taskName]
+		for _, dependency := range task.dependencies {
+			dependencyTask := taskMap[dependency]
+			if dependencyTask == nil {
+				continue
+			}
+			if slices.Contains(dependencyTask.dependees, taskName) {
+				// Dependees are converted to dependencies, so do not add them twice
+				continue
+			}
+			edges = append(edges, graphEdge{from: dependency, to: taskName, kind: graphEdgeDependency})
+		}
+		for _, dependee := range task.dependees {
+			if taskMap[dependee] == nil {
+				continue
+			}
+			edges = append(edges, graphEdge{from: taskName, to: dependee, kind: graphEdgeDependee})
+		}
+		for _, followup := range task.followups {
+			if taskMap[followup] == nil {
+				continue
+			}
+			edges = append(edges, graphEdge{from: taskName, to: followup, kind: graphEdgeFollowup})
+		}
+	}
+	return edges
+}
+
+func writeGraphDot(w io.Writer, edges []graphEdge) error {
+	styles := map[graphEdgeKind]string{
+		graphEdgeDependency: "solid",
+		graphEdgeDependee:   "dashed",
+		graphEdgeFollowup:   "dotted",
+	}
+	var sb strings.Builder
+	sb.WriteString("digraph gotaskr {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box];\n")
+	for _, taskName := range taskList {
+		fmt.Fprintf(&sb, "  %s;\n", quoteDot(taskName))
+	}
+	for _, edge := range edges {
+		fmt.Fprintf(&sb, "  %s -> %s [style=%s, label=%s];\n", quoteDot(edge.from), quoteDot(edge.to), styles[edge.kind], quoteDot(string(edge.kind)))
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeGraphMermaid(w io.Writer, edges []graphEdge) error {
+	arrows := map[graphEdgeKind]string{
+		graphEdgeDependency: "-->",
+		graphEdgeDependee:   "-.->",
+		graphEdgeFollowup:   "==>",
+	}
+	// Mermaid does not allow special characters in the ids, so use generated ones
+	ids := map[string]string{}
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for i, taskName := range taskList {
+		ids[taskName] = fmt.Sprintf("task%d", i)
+		fmt.Fprintf(&sb, "  %s[\"%s\"]\n", ids[taskName], strings.ReplaceAll(taskName, "\"", "#quot;"))
+	}
+	for _, edge := range edges {
+		fmt.Fprintf(&sb, "  %s %s|%s| %s\n", ids[edge.from], arrows[edge.kind], edge.kind, ids[edge.to])
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func quoteDot(value string) string {
+	return "\"" + strings.ReplaceAll(value, "\"", "\\\"") + "\""
+}
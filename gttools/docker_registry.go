@@ -2,6 +2,7 @@ package gttools
 
 import (
 	"github.com/roemer/goext"
+	"github.com/roemer/gotaskr/internal/secrets"
 )
 
 // DockerRegistryTool provides access to the helper methods for Docker Registries.
@@ -22,6 +23,7 @@ func (tool *DockerRegistryTool) Login(settings *DockerLoginSettings) error {
 	}
 	args = append(args, "--username", settings.Username)
 	args = append(args, "--password", settings.Password)
+	secrets.Register(settings.Password)
 	args = append(args, settings.CustomArguments...)
 	args = goext.SliceAppendIf(args, settings.Registry != "", settings.Registry)
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/roemer/goext"
+	"github.com/roemer/gotaskr/internal/secrets"
 	"github.com/roemer/gotaskr/internal/utils"
 )
 
@@ -197,6 +198,7 @@ func (tool *FlywayTool) buildArguments(settings *FlywaySettings) []string {
 	args = addString(args, settings.Url, addSettings{prefix: "-url="})
 	args = addString(args, settings.User, addSettings{prefix: "-user="})
 	args = addString(args, settings.Password, addSettings{prefix: "-password="})
+	secrets.Register(settings.Password)
 	args = addString(args, settings.Driver, addSettings{prefix: "-driver="})
 	args = addInt(args, settings.ConnectRetries, addSettings{prefix: "-connectRetries="})
 	args = addInt(args, settings.ConnectRetriesInterval, addSettings{prefix: "-connectRetriesInterval="})
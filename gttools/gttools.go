@@ -2,29 +2,108 @@
 package gttools
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/roemer/goext"
+	"github.com/roemer/gotaskr/internal/secrets"
+	"github.com/roemer/gotaskr/internal/toolctx"
 )
 
+// The time to wait for the output of a killed process before giving up.
+const killWaitDelay = 5 * time.Second
+
 type ToolBase struct {
 }
 
 func (tool *ToolBase) run(binPath string, args []string, settings ToolSettingsBase) error {
-	return goext.NewCmdRunner().
-		WithWorkingDirectory(settings.WorkingDirectory).
-		SetConsoleOutput(settings.OutputToConsole).
-		WithLogFile(settings.LogFilePath).
-		Run(binPath, args...)
+	cmd, cleanup, err := tool.prepareCmd(binPath, args, settings, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return cmd.Run()
 }
 
 func (tool *ToolBase) runGetOutput(binPath string, args []string, settings ToolSettingsBase) (string, string, error) {
-	return goext.NewCmdRunner().
-		WithWorkingDirectory(settings.WorkingDirectory).
-		SetConsoleOutput(settings.OutputToConsole).
-		WithLogFile(settings.LogFilePath).
-		RunGetOutput(binPath, args...)
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd, cleanup, err := tool.prepareCmd(binPath, args, settings, &stdoutBuf, &stderrBuf)
+	if err != nil {
+		return "", "", err
+	}
+	defer cleanup()
+	err = cmd.Run()
+	return goext.StringTrimNewlineSuffix(stdoutBuf.String()), goext.StringTrimNewlineSuffix(stderrBuf.String()), err
+}
+
+// prepareCmd creates the command for the tool which writes the output to the console, the log file and the given buffers.
+// The registered secrets are masked in the console and log file output.
+// The process is killed when the context from the settings is cancelled. Without a context in the settings,
+// the context of the running task is used if it does not run in parallel with other tasks.
+// The CmdRunner of goext is not used as it cannot kill the process when a context is cancelled.
+func (tool *ToolBase) prepareCmd(binPath string, args []string, settings ToolSettingsBase, stdoutBuf io.Writer, stderrBuf io.Writer) (*exec.Cmd, func(), error) {
+	ctx := settings.Context
+	if ctx == nil {
+		ctx = toolctx.Get()
+	}
+	// Remove empty arguments that might cause issues on some platforms (e.g. Windows)
+	args = slices.DeleteFunc(slices.Clone(args), func(arg string) bool {
+		return arg == ""
+	})
+	cmd := exec.CommandContext(ctx, binPath, args...)
+	cmd.Dir = settings.WorkingDirectory
+	cmd.WaitDelay = killWaitDelay
+
+	// Prepare the writers
+	cleanup := func() {}
+	stdoutWriters := []io.Writer{}
+	stderrWriters := []io.Writer{}
+	if settings.OutputToConsole {
+		stdoutWriters = append(stdoutWriters, os.Stdout)
+		stderrWriters = append(stderrWriters, os.Stderr)
+	}
+	if settings.LogFilePath != "" {
+		if err := os.MkdirAll(filepath.Dir(settings.LogFilePath), os.ModePerm); err != nil {
+			return nil, nil, err
+		}
+		logFile, err := os.OpenFile(settings.LogFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		cleanup = func() {
+			logFile.Close()
+		}
+		stdoutWriters = append(stdoutWriters, logFile)
+		stderrWriters = append(stderrWriters, logFile)
+	}
+	// Mask the secrets in the output that is visible to the user
+	stdoutMasker := secrets.NewMaskingWriter(io.MultiWriter(stdoutWriters...))
+	stderrMasker := secrets.NewMaskingWriter(io.MultiWriter(stderrWriters...))
+	closeLogFile := cleanup
+	cleanup = func() {
+		stdoutMasker.Flush()
+		stderrMasker.Flush()
+		closeLogFile()
+	}
+	stdoutWriters = []io.Writer{stdoutMasker}
+	stderrWriters = []io.Writer{stderrMasker}
+	if stdoutBuf != nil {
+		stdoutWriters = append(stdoutWriters, stdoutBuf)
+	}
+	if stderrBuf != nil {
+		stderrWriters = append(stderrWriters, stderrBuf)
+	}
+	cmd.Stdout = io.MultiWriter(stdoutWriters...)
+	cmd.Stderr = io.MultiWriter(stderrWriters...)
+	return cmd, cleanup, nil
 }
 
 // ToolsClient provides typed access to the different tools.
@@ -61,10 +140,11 @@ func CreateToolsClient() *ToolsClient {
 
 // ToolSettingsBase are common settings useful for all tools that run executables.
 type ToolSettingsBase struct {
-	WorkingDirectory string   // the path to use as working directory when running the tool
-	OutputToConsole  bool     // flag to define if the output of the tool should be written into the console or not.
-	LogFilePath      string   // if set, the output of the tool will be written to the given file path
-	CustomArguments  []string // list with custom arguments passed to the tool
+	WorkingDirectory string          // the path to use as working directory when running the tool
+	OutputToConsole  bool            // flag to define if the output of the tool should be written into the console or not.
+	LogFilePath      string          // if set, the output of the tool will be written to the given file path
+	CustomArguments  []string        // list with custom arguments passed to the tool
+	Context          context.Context // the process of the tool is killed when the context is cancelled. Uses the context of the running task if not set and the task does not run in parallel
 }
 
 // Customize adds a custom argument to the settings object.
@@ -73,6 +153,12 @@ func (s *ToolSettingsBase) Customize(setting ...string) *ToolSettingsBase {
 	return s
 }
 
+// WithContext sets the context which kills the process of the tool when it is cancelled.
+func (s *ToolSettingsBase) WithContext(ctx context.Context) *ToolSettingsBase {
+	s.Context = ctx
+	return s
+}
+
 // Ptr is a helper returns a pointer to v.
 func Ptr[T any](v T) *T {
 	return &v
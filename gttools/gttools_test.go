@@ -1,8 +1,15 @@
 package gttools
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
+	"github.com/roemer/gotaskr/internal/secrets"
+	"github.com/roemer/gotaskr/internal/toolctx"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,3 +48,57 @@ func TestAddListSeparateWitPrepend(t *testing.T) {
 
 	assert.Equal([]string{"--mysetting", "a", "--mysetting", "b", "--mysetting", "c"}, args)
 }
+
+func TestRunWithCancelledContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep is not available on windows")
+	}
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	tool := &ToolBase{}
+	start := time.Now()
+	err := tool.run("sleep", []string{"5"}, ToolSettingsBase{Context: ctx})
+
+	assert.Error(err)
+	assert.Less(time.Since(start), 5*time.Second)
+}
+
+func TestRunMasksSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("echo is not available on windows")
+	}
+	assert := assert.New(t)
+
+	secrets.Register("my-password")
+	defer secrets.Clear()
+	logFilePath := filepath.Join(t.TempDir(), "tool.log")
+	tool := &ToolBase{}
+	stdout, _, err := tool.runGetOutput("echo", []string{"login with my-password"}, ToolSettingsBase{LogFilePath: logFilePath})
+	assert.NoError(err)
+
+	logContent, err := os.ReadFile(logFilePath)
+	assert.NoError(err)
+	assert.Equal("login with ***\n", string(logContent))
+	// The output returned to the caller is not masked
+	assert.Equal("login with my-password", stdout)
+}
+
+func TestRunUsesTaskContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep is not available on windows")
+	}
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	toolctx.Set(ctx)
+	defer toolctx.Set(context.Background())
+	tool := &ToolBase{}
+	start := time.Now()
+	err := tool.run("sleep", []string{"5"}, ToolSettingsBase{})
+
+	assert.Error(err)
+	assert.Less(time.Since(start), 5*time.Second)
+}
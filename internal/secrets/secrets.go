@@ -0,0 +1,103 @@
+// Package secrets provides a registry of secret values which are masked in all output.
+package secrets
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// The text which replaces the secrets.
+const Mask = "***"
+
+var mutex sync.RWMutex
+var secrets = []string{}
+
+// Register adds the given values to the secrets. Empty values are ignored.
+func Register(values ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, value := range values {
+		if value == "" || slices.Contains(secrets, value) {
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+	// Mask longer secrets first in case they contain shorter ones
+	slices.SortStableFunc(secrets, func(a, b string) int {
+		return len(b) - len(a)
+	})
+}
+
+// Clear removes all registered secrets.
+func Clear() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	secrets = []string{}
+}
+
+// MaskText replaces all registered secrets in the given text.
+func MaskText(text string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, Mask)
+	}
+	return text
+}
+
+// getPartialSecretLength gets the length of the longest suffix of the data which is the start of a secret.
+func getPartialSecretLength(data []byte) int {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	longest := 0
+	for _, secret := range secrets {
+		for length := min(len(secret)-1, len(data)); length > longest; length-- {
+			if bytes.HasSuffix(data, []byte(secret[:length])) {
+				longest = length
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// MaskingWriter is a writer which masks the registered secrets before writing to the underlying writer.
+// Data which could be the start of a secret is held back until the next write or Flush.
+type MaskingWriter struct {
+	writer  io.Writer
+	pending []byte
+	mutex   sync.Mutex
+}
+
+// NewMaskingWriter creates a new writer which masks the secrets before writing to the given writer.
+func NewMaskingWriter(writer io.Writer) *MaskingWriter {
+	return &MaskingWriter{writer: writer}
+}
+
+// Write masks the secrets in the given data and writes it to the underlying writer.
+func (w *MaskingWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	masked := []byte(MaskText(string(append(w.pending, p...))))
+	holdBack := getPartialSecretLength(masked)
+	w.pending = slices.Clone(masked[len(masked)-holdBack:])
+	if _, err := w.writer.Write(masked[:len(masked)-holdBack]); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the data which was held back to the underlying writer.
+func (w *MaskingWriter) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.pending) == 0 {
+		return nil
+	}
+	_, err := w.writer.Write(w.pending)
+	w.pending = nil
+	return err
+}
@@ -0,0 +1,39 @@
+package secrets
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskText(t *testing.T) {
+	assert := assert.New(t)
+	Clear()
+	defer Clear()
+
+	Register("secret", "", "my-secret-value")
+
+	assert.Equal("login with *** and ***", MaskText("login with secret and my-secret-value"))
+	assert.Equal("nothing to hide", MaskText("nothing to hide"))
+}
+
+func TestMaskingWriter(t *testing.T) {
+	assert := assert.New(t)
+	Clear()
+	defer Clear()
+
+	Register("password123")
+	var buf bytes.Buffer
+	writer := NewMaskingWriter(&buf)
+
+	// Write the secret split over multiple writes
+	for _, chunk := range []string{"the pass", "word", "123 is hidden, the pass"} {
+		n, err := writer.Write([]byte(chunk))
+		assert.NoError(err)
+		assert.Equal(len(chunk), n)
+	}
+	assert.Equal("the *** is hidden, the ", buf.String())
+	assert.NoError(writer.Flush())
+	assert.Equal("the *** is hidden, the pass", buf.String())
+}
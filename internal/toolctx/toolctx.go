@@ -0,0 +1,24 @@
+// Package toolctx holds the context which is used by the tools when their settings do not have one.
+package toolctx
+
+import (
+	"context"
+	"sync"
+)
+
+var mutex sync.Mutex
+var current = context.Background()
+
+// Set sets the context which is used by the tools when their settings do not have one.
+func Set(ctx context.Context) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current = ctx
+}
+
+// Get gets the context which is used by the tools when their settings do not have one.
+func Get() context.Context {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current
+}
@@ -1,6 +1,10 @@
 package log
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/roemer/gotaskr/internal/secrets"
+)
 
 var Newline string = fmt.Sprintln()
 
@@ -30,7 +34,8 @@ func logNoopf(format string, a ...any) int {
 }
 
 func logWrite(a ...any) int {
-	n, _ := fmt.Println(a...)
+	// Mask the registered secrets
+	n, _ := fmt.Print(secrets.MaskText(fmt.Sprintln(a...)))
 	return n
 }
 
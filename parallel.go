@@ -0,0 +1,222 @@
+package gotaskr
+
+// parallelNodeState is the state of a task within a parallel run.
+type parallelNodeState int
+
+const (
+	nodePending parallelNodeState = iota
+	nodeRunning
+	nodeDone
+)
+
+// parallelNode represents a task within the dependency graph of a parallel run.
+type parallelNode struct {
+	task         *TaskObject
+	dependencies []*parallelNode
+	dependents   []*parallelNode
+	state        parallelNodeState
+	err          error // The error that is passed on to the dependents.
+}
+
+// parallelResult is sent from a worker when a task finished.
+type parallelResult struct {
+	node *parallelNode
+	ran  bool // A flag to indicate if the task itself ran without error.
+	err  error
+}
+
+// runTargetParallel runs the dependency graph of the given target with the given number of workers.
+// Tasks marked as parallelizable run concurrently, all other tasks run exclusively.
+// Followups are run once the dependency graph has finished.
+func runTargetParallel(target *TaskObject, workers int) error {
+	targetNode, order := buildParallelGraph(target)
+	results := make(chan parallelResult)
+	running := 0
+	exclusiveRunning := false
+	completed := []*TaskObject{}
+
+	for {
+		// Start all the tasks that are ready
+		if !exclusiveRunning {
+			for _, node := range order {
+				if node.state != nodePending || !node.dependenciesDone() {
+					continue
+				}
+				// Handle failed dependencies
+				if depErr := node.handleDependencyErrors(); depErr != nil {
+					node.finish(depErr)
+					continue
+				}
+				// Skip tasks which are not needed anymore
+				if !node.isNeeded() {
+					node.finish(nil)
+					continue
+				}
+				if !node.task.parallelizable {
+					// Exclusive tasks wait until no other task is running
+					if running == 0 {
+						node.start(results)
+						running++
+						exclusiveRunning = true
+					}
+					break
+				}
+				if running >= workers {
+					break
+				}
+				node.start(results)
+				running++
+			}
+		}
+		if running == 0 {
+			break
+		}
+
+		// Wait for a task to finish
+		result := <-results
+		running--
+		exclusiveRunning = false
+		result.node.finish(result.err)
+		if result.ran && result.node.task.runsFollowups() {
+			completed = append(completed, result.node.task)
+		}
+	}
+
+	// Abort if the target itself could not run
+	if !target.didRun {
+		return targetNode.err
+	}
+
+	// Run followup tasks
+	for _, task := range completed {
+		for _, followup := range task.followups {
+			followupErr := RunTarget(followup)
+			if followupErr != nil {
+				if task.deferOnError {
+					// Handle deferred errors
+					task.deferredErr = followupErr
+				} else {
+					return followupErr
+				}
+			}
+		}
+	}
+	if target.deferredErr != nil {
+		return target.deferredErr
+	}
+	return targetNode.err
+}
+
+// buildParallelGraph builds the dependency graph of the given target.
+// Returns the node of the target and all nodes in the order a sequential run would use.
+func buildParallelGraph(target *TaskObject) (*parallelNode, []*parallelNode) {
+	nodes := map[string]*parallelNode{}
+	onStack := map[string]bool{}
+	order := []*parallelNode{}
+
+	var visit func(task *TaskObject) *parallelNode
+	visit = func(task *TaskObject) *parallelNode {
+		if node, exists := nodes[task.name]; exists {
+			return node
+		}
+		node := &parallelNode{task: task}
+		nodes[task.name] = node
+		if task.didRun {
+			// Tasks which already ran are done and their dependencies are not needed
+			node.state = nodeDone
+			node.err = task.err
+			order = append(order, node)
+			return node
+		}
+		if isExcluded(task.name) {
+			// Tasks which are excluded do not need their dependencies
+			order = append(order, node)
+			return node
+		}
+		onStack[task.name] = true
+		for _, dependency := range task.dependencies {
+			if onStack[dependency] {
+				// Ignore cyclic dependencies
+				continue
+			}
+			dependencyNode := visit(taskMap[dependency])
+			node.dependencies = append(node.dependencies, dependencyNode)
+			dependencyNode.dependents = append(dependencyNode.dependents, node)
+		}
+		onStack[task.name] = false
+		order = append(order, node)
+		return node
+	}
+	targetNode := visit(target)
+	return targetNode, order
+}
+
+// dependenciesDone checks if all dependencies of the node are done.
+func (node *parallelNode) dependenciesDone() bool {
+	for _, dependency := range node.dependencies {
+		if dependency.state != nodeDone {
+			return false
+		}
+	}
+	return true
+}
+
+// handleDependencyErrors defers the errors of the dependencies if the task wants it.
+// Returns the first error that is not deferred.
+func (node *parallelNode) handleDependencyErrors() error {
+	for _, dependency := range node.dependencies {
+		if dependency.err == nil {
+			continue
+		}
+		if !node.task.deferOnError {
+			return dependency.err
+		}
+		node.task.deferredErr = dependency.err
+	}
+	return nil
+}
+
+// isNeeded checks if the node still needs to run for any of the pending dependents.
+func (node *parallelNode) isNeeded() bool {
+	if node.state == nodeDone {
+		return false
+	}
+	if len(node.dependents) == 0 {
+		// This is the target
+		return true
+	}
+	for _, dependent := range node.dependents {
+		if dependent.isNeeded() {
+			return true
+		}
+	}
+	return false
+}
+
+// start runs the task of the node in a new goroutine and reports to the given channel when finished.
+func (node *parallelNode) start(results chan<- parallelResult) {
+	node.state = nodeRunning
+	go func() {
+		err := runTask(node.task)
+		result := parallelResult{node: node, ran: err == nil, err: err}
+		if err == nil {
+			result.err = node.task.deferredErr
+		}
+		results <- result
+	}()
+}
+
+// finish marks the node as done with the given error.
+// The error is directly passed on to pending dependents which do not defer errors.
+func (node *parallelNode) finish(err error) {
+	node.state = nodeDone
+	node.err = err
+	if err == nil {
+		return
+	}
+	for _, dependent := range node.dependents {
+		if dependent.state == nodePending && !dependent.task.deferOnError {
+			dependent.finish(err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package gotaskr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/roemer/gotaskr/log"
+)
+
+// planEntry represents a task within the resolved execution plan.
+type planEntry struct {
+	task   *TaskObject // The task to run.
+	reason string      // The reason why the task is part of the plan.
+}
+
+// resolveExecutionPlan resolves the tasks that would run for the given targets in the order RunTarget runs them.
+func resolveExecutionPlan(targets ...string) ([]*planEntry, error) {
+	plan := []*planEntry{}
+	planned := map[string]bool{}
+	visiting := map[string]bool{}
+	exclusive := isExclusive()
+
+	var resolve func(taskName string, reason string) error
+	resolve = func(taskName string, reason string) error {
+		task := taskMap[taskName]
+		if task == nil {
+			return fmt.Errorf("target does not exist: %s", taskName)
+		}
+		if planned[taskName] || visiting[taskName] {
+			return nil
+		}
+		visiting[taskName] = true
+		defer delete(visiting, taskName)
+		excluded := isExcluded(taskName)
+		// Resolve the dependencies
+		if !exclusive && !excluded {
+			for _, dependency := range task.dependencies {
+				dependencyTask := taskMap[dependency]
+				dependencyReason := fmt.Sprintf("dependency of %s", taskName)
+				if dependencyTask != nil && slices.Contains(dependencyTask.dependees, taskName) {
+					dependencyReason = fmt.Sprintf("dependee of %s", taskName)
+				}
+				if err := resolve(dependency, dependencyReason); err != nil {
+					return err
+				}
+			}
+		}
+		// Add the task itself
+		planned[taskName] = true
+		plan = append(plan, &planEntry{task: task, reason: reason})
+		// Resolve the followups
+		if !exclusive && task.runsFollowups() {
+			for _, followup := range task.followups {
+				if err := resolve(followup, fmt.Sprintf("followup of %s", taskName)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, target := range targets {
+		if err := resolve(target, "target"); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// printExecutionPlan prints the resolved execution plan of the given targets without running anything.
+func printExecutionPlan(targets []string) int {
+	plan, err := resolveExecutionPlan(targets...)
+	if err != nil {
+		color.Red("%v", err)
+		return 1
+	}
+	log.Informationf("Execution plan for '%s':", strings.Join(targets, ", "))
+	var sb strings.Builder
+	for i, entry := range plan {
+		markers := ""
+		if entry.task.parallelizable {
+			markers += " [parallelizable]"
+		}
+		if len(entry.task.criterias) > 0 {
+			markers += " [conditional]"
+		}
+		if reason, excluded := getExclusionReason(entry.task.name); excluded {
+			markers += fmt.Sprintf(" [skipped: %s]", reason)
+		}
+		fmt.Fprintf(&sb, "%3d. %-50s(%s)%s", i+1, entry.task.name, entry.reason, markers)
+		sb.WriteString(log.Newline)
+	}
+	log.Information(sb.String())
+	return 0
+}
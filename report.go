@@ -0,0 +1,273 @@
+package gotaskr
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/roemer/goext"
+	"github.com/roemer/gotaskr/internal/secrets"
+)
+
+// The possible states of a task run.
+const (
+	taskStatusSuccess  = "Success"
+	taskStatusFailed   = "Failed"
+	taskStatusSkipped  = "Skipped"
+	taskStatusUpToDate = "Up-to-date"
+)
+
+// jsonReport is the machine-readable report of a gotaskr run.
+type jsonReport struct {
+	ExitCode int               `json:"exitCode"`
+	Targets  []string          `json:"targets,omitempty"`
+	Tasks    []*jsonReportTask `json:"tasks"`
+}
+
+type jsonReportTask struct {
+	Name             string                       `json:"name"`
+	Status           string                       `json:"status"`
+	ExitCode         int                          `json:"exitCode"`
+	StartTime        time.Time                    `json:"startTime"`
+	DurationSeconds  float64                      `json:"durationSeconds"`
+	Attempts         int                          `json:"attempts,omitempty"`
+	SkipReason       string                       `json:"skipReason,omitempty"`
+	Error            string                       `json:"error,omitempty"`
+	IgnoredError     string                       `json:"ignoredError,omitempty"`
+	DeferredError    string                       `json:"deferredError,omitempty"`
+	TimeMeasurements []*jsonReportTimeMeasurement `json:"timeMeasurements,omitempty"`
+}
+
+type jsonReportTimeMeasurement struct {
+	Name            string    `json:"name"`
+	StartTime       time.Time `json:"startTime"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	Attempt         int       `json:"attempt,omitempty"`
+}
+
+// junitTestSuites is the root of a JUnit report of a gotaskr run.
+type junitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Name       string            `xml:"name,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Skipped    int               `xml:"skipped,attr"`
+	Time       string            `xml:"time,attr"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr,omitempty"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeReports persists the state of the run and writes the reports which were requested with the arguments.
+// Returns the exit code which is changed to a failure if a report could not be written.
+func writeReports(targets []string, exitCode int) int {
+	if err := writeRunState(targets, exitCode); err != nil {
+		color.Yellow("Failed to save the state of the run: %v", err)
+	}
+	if reportPath, exists := GetArgument("report-json"); exists {
+		if err := writeJsonReport(reportPath, targets, exitCode); err != nil {
+			color.Red("Failed to write the json report: %v", err)
+			exitCode = goext.Ternary(exitCode == 0, 1, exitCode)
+		}
+	}
+	if reportPath, exists := GetArgument("report-junit"); exists {
+		if err := writeJUnitReport(reportPath); err != nil {
+			color.Red("Failed to write the junit report: %v", err)
+			exitCode = goext.Ternary(exitCode == 0, 1, exitCode)
+		}
+	}
+	return exitCode
+}
+
+// writeJsonReport writes the runs of the tasks as json to the given path.
+func writeJsonReport(reportPath string, targets []string, exitCode int) error {
+	report := createJsonReport(targets, exitCode)
+	if err := os.MkdirAll(filepath.Dir(reportPath), os.ModePerm); err != nil {
+		return err
+	}
+	return goext.WriteJsonToFile(report, reportPath, true)
+}
+
+// writeRunState persists the outcome of the run so the failed tasks can be rerun.
+func writeRunState(targets []string, exitCode int) error {
+	return writeJsonReport(getRunStatePath(), targets, exitCode)
+}
+
+// loadRunState loads the outcome of the previous run.
+func loadRunState() (*jsonReport, error) {
+	data, err := os.ReadFile(getRunStatePath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no previous run found")
+		}
+		return nil, err
+	}
+	report := &jsonReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func getRunStatePath() string {
+	return filepath.Join(getStateDirectory(), "last-run.json")
+}
+
+// getSucceededTasks gets the names of the tasks which did not fail in the report.
+func (report *jsonReport) getSucceededTasks() map[string]bool {
+	succeeded := map[string]bool{}
+	for _, task := range report.Tasks {
+		if task.Status != taskStatusFailed {
+			succeeded[task.Name] = true
+		}
+	}
+	return succeeded
+}
+
+// createJsonReport creates the json report of the runs of the tasks.
+func createJsonReport(targets []string, exitCode int) *jsonReport {
+	report := &jsonReport{
+		ExitCode: exitCode,
+		Targets:  targets,
+		Tasks:    []*jsonReportTask{},
+	}
+	for _, run := range taskRun {
+		reportTask := &jsonReportTask{
+			Name:            run.name,
+			Status:          getTaskStatus(run),
+			ExitCode:        getExitCodeFromTaskRun(run),
+			StartTime:       run.startTime,
+			DurationSeconds: run.duration.Seconds(),
+			Attempts:        run.attempts,
+			SkipReason:      secrets.MaskText(run.skipReason),
+			Error:           errorText(run.err),
+			IgnoredError:    errorText(run.ignoredErr),
+			DeferredError:   errorText(run.deferredErr),
+		}
+		for _, measurement := range run.timeMeasurements {
+			reportTask.TimeMeasurements = append(reportTask.TimeMeasurements, &jsonReportTimeMeasurement{
+				Name:            secrets.MaskText(measurement.name),
+				StartTime:       measurement.startTime,
+				DurationSeconds: measurement.duration.Seconds(),
+				Attempt:         measurement.attempt,
+			})
+		}
+		report.Tasks = append(report.Tasks, reportTask)
+	}
+	return report
+}
+
+// writeJUnitReport writes the runs of the tasks as JUnit XML to the given path.
+// Each task run is written as a testcase.
+func writeJUnitReport(reportPath string) error {
+	suite := &junitTestSuite{
+		Name:      "gotaskr",
+		TestCases: []*junitTestCase{},
+	}
+	totalDuration := time.Duration(0)
+	for _, run := range taskRun {
+		testCase := &junitTestCase{
+			Name:      run.name,
+			ClassName: "gotaskr",
+			Time:      formatJUnitDuration(run.duration),
+		}
+		switch getTaskStatus(run) {
+		case taskStatusSkipped:
+			testCase.Skipped = &junitMessage{Message: secrets.MaskText(run.skipReason)}
+			suite.Skipped++
+		case taskStatusFailed:
+			failures := []string{}
+			if run.err != nil {
+				failures = append(failures, fmt.Sprintf("Task error: %s", errorText(run.err)))
+			}
+			if run.deferredErr != nil {
+				failures = append(failures, fmt.Sprintf("Deferred error: %s", errorText(run.deferredErr)))
+			}
+			testCase.Failure = &junitMessage{Message: failures[0], Text: strings.Join(failures, "\n")}
+			suite.Failures++
+		case taskStatusUpToDate:
+			testCase.SystemOut = "Up-to-date"
+		}
+		if run.ignoredErr != nil {
+			testCase.SystemOut = fmt.Sprintf("Ignored error: %s", errorText(run.ignoredErr))
+		}
+		if suite.Timestamp == "" && !run.startTime.IsZero() {
+			suite.Timestamp = run.startTime.Format("2006-01-02T15:04:05")
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Tests++
+		totalDuration += run.duration
+	}
+	suite.Time = formatJUnitDuration(totalDuration)
+	report := &junitTestSuites{
+		Name:       suite.Name,
+		Tests:      suite.Tests,
+		Failures:   suite.Failures,
+		Skipped:    suite.Skipped,
+		Time:       suite.Time,
+		TestSuites: []*junitTestSuite{suite},
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(reportPath), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(reportPath, append([]byte(xml.Header), data...), 0644)
+}
+
+func formatJUnitDuration(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
+
+// getTaskStatus gets the status of the given task run.
+func getTaskStatus(run *TaskObject) string {
+	if run.skipped {
+		return taskStatusSkipped
+	}
+	if run.upToDate {
+		return taskStatusUpToDate
+	}
+	if run.err != nil || run.deferredErr != nil {
+		return taskStatusFailed
+	}
+	return taskStatusSuccess
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return secrets.MaskText(err.Error())
+}